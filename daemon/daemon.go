@@ -0,0 +1,295 @@
+// Package daemon keeps a long-lived renderer on a Unix socket so each status
+// line render skips process startup and reuses in-memory caches.
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/mrdavidaylward/ccstatus/internal/cache"
+	"github.com/mrdavidaylward/ccstatus/internal/debug"
+	"github.com/mrdavidaylward/ccstatus/render"
+	"github.com/mrdavidaylward/ccstatus/schema"
+	"github.com/mrdavidaylward/ccstatus/source"
+	"github.com/mrdavidaylward/ccstatus/usage"
+)
+
+// Daemon mode: `ccstatus daemon` listens on a Unix socket and renders status
+// lines with warm in-memory state. The regular `ccstatus` command acts as a
+// thin client when the socket answers, and renders in-process otherwise.
+
+const (
+	daemonDialTimeout     = 50 * time.Millisecond
+	daemonResponseTimeout = 3 * time.Second        // Long enough for a cold render with no earlier line to answer
+	daemonRenderBudget    = 300 * time.Millisecond // After this, a slow render is answered with the session's last line
+	daemonRefreshInterval = time.Second
+	daemonIdleTimeout     = 10 * time.Minute // Workspaces and sessions not rendered for this long are dropped
+)
+
+// State kept between renders, keyed by client cache dir and session or workspace
+var (
+	stateMux   sync.Mutex
+	lastLines  = map[string]lastLine{}
+	workspaces = map[string]watchedWorkspace{}
+)
+
+// lastLine is a session's last successful render
+type lastLine struct {
+	response daemonResponse
+	rendered time.Time
+}
+
+// watchedWorkspace is a workspace whose git status the daemon keeps fresh
+type watchedWorkspace struct {
+	dir  string
+	env  schema.Env
+	seen time.Time
+}
+
+// daemonRequest carries the client's stdin and environment to the daemon
+type daemonRequest struct {
+	Input json.RawMessage `json:"input"`
+	Env   []string        `json:"env"`
+}
+
+// daemonResponse carries the rendered line and its warnings, or the error that prevented it
+type daemonResponse struct {
+	Output   string   `json:"output"`
+	Warnings []string `json:"warnings,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// SocketPath returns the daemon socket location, honoring CCSTATUS_SOCKET and XDG_RUNTIME_DIR
+func SocketPath() string {
+	if path := os.Getenv("CCSTATUS_SOCKET"); path != "" {
+		return path
+	}
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		return filepath.Join(runtimeDir, "ccstatus.sock")
+	}
+	if cacheDir := cache.Dir(nil); cacheDir != "" {
+		return filepath.Join(cacheDir, "daemon.sock")
+	}
+	return ""
+}
+
+// Render asks a running daemon to render input, returning the line and the warnings
+// render.Render reported for it; ok is false if no daemon answered in time
+func Render(input []byte) (output string, warnings []error, ok bool) {
+	socketPath := SocketPath()
+	if socketPath == "" || !json.Valid(input) {
+		return "", nil, false
+	}
+
+	conn, err := net.DialTimeout("unix", socketPath, daemonDialTimeout)
+	if err != nil {
+		return "", nil, false
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(daemonResponseTimeout))
+
+	request := daemonRequest{Input: input, Env: os.Environ()}
+	if err := json.NewEncoder(conn).Encode(request); err != nil {
+		debug.Log("Failed to send request to daemon: %v", err)
+		return "", nil, false
+	}
+
+	var response daemonResponse
+	if err := json.NewDecoder(conn).Decode(&response); err != nil {
+		debug.Log("No response from daemon: %v", err)
+		return "", nil, false
+	}
+	if response.Error != "" {
+		debug.Log("Daemon failed to render: %s", response.Error)
+		return "", nil, false
+	}
+	for _, warning := range response.Warnings {
+		warnings = append(warnings, errors.New(warning))
+	}
+	return response.Output, warnings, true
+}
+
+// Run serves render requests on the socket until interrupted
+func Run() error {
+	socketPath := SocketPath()
+	if socketPath == "" {
+		return errors.New("no socket path available")
+	}
+
+	// A socket that still answers belongs to a live daemon; otherwise it is stale
+	if conn, err := net.DialTimeout("unix", socketPath, daemonDialTimeout); err == nil {
+		conn.Close()
+		return fmt.Errorf("daemon already running on %s", socketPath)
+	}
+	os.Remove(socketPath)
+
+	if err := os.MkdirAll(filepath.Dir(socketPath), 0700); err != nil {
+		return err
+	}
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(socketPath)
+	if err := os.Chmod(socketPath, 0600); err != nil {
+		listener.Close()
+		return err
+	}
+
+	cache.EnableMemory()
+	go refreshState()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		listener.Close()
+	}()
+
+	debug.Log("Daemon listening on %s", socketPath)
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go handleDaemonConn(conn)
+	}
+}
+
+// handleDaemonConn renders a single request
+func handleDaemonConn(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(daemonResponseTimeout))
+
+	var request daemonRequest
+	var response daemonResponse
+	if err := json.NewDecoder(conn).Decode(&request); err != nil {
+		if err == io.EOF {
+			return // Liveness probe
+		}
+		response.Error = err.Error()
+		if err := json.NewEncoder(conn).Encode(response); err != nil {
+			debug.Log("Failed to send response: %v", err)
+		}
+		return
+	}
+
+	// Everything is looked up in, and every process run with, the client's environment
+	env := schema.Env(request.Env)
+	if env == nil {
+		env = schema.Env{}
+	}
+	input, _ := schema.Parse(request.Input) // render.Render reports invalid input
+	sessionKey := ""
+	if input.SessionID != "" {
+		sessionKey = cache.Key(cache.Dir(env), input.SessionID)
+	}
+	watchWorkspace(input.WorkspacePath(), env)
+
+	done := make(chan daemonResponse, 1)
+	go func() {
+		response := renderResponse(request.Input, env)
+		if response.Error == "" && sessionKey != "" {
+			stateMux.Lock()
+			lastLines[sessionKey] = lastLine{response: response, rendered: time.Now()}
+			stateMux.Unlock()
+		}
+		done <- response
+
+		// The client's cache may not be ours; the in-process path prunes after rendering too
+		cache.Prune(env)
+	}()
+
+	// A cold cache can make a render slower than the client waits for; rather than
+	// have it render the same line again in-process, answer with the session's last line
+	select {
+	case response = <-done:
+	case <-time.After(daemonRenderBudget):
+		stateMux.Lock()
+		last, ok := lastLines[sessionKey]
+		stateMux.Unlock()
+		if ok {
+			debug.Log("Render for session %s is slow, answering with its last line", input.SessionID)
+			response = last.response
+		} else {
+			response = <-done
+		}
+	}
+	if err := json.NewEncoder(conn).Encode(response); err != nil {
+		debug.Log("Failed to send response: %v", err)
+	}
+}
+
+// renderResponse renders input with env into a response for the client
+func renderResponse(input []byte, env schema.Env) daemonResponse {
+	var response daemonResponse
+	output, warnings, err := render.Render(input, env)
+	if err != nil {
+		response.Error = err.Error()
+	}
+	response.Output = output
+	// Warnings are the client's to print, as they would be rendering in-process
+	for _, warning := range warnings {
+		response.Warnings = append(response.Warnings, warning.Error())
+	}
+	return response
+}
+
+// watchWorkspace has the refresh loop keep dir's git status fresh in env's cache
+func watchWorkspace(dir string, env schema.Env) {
+	if !filepath.IsAbs(dir) {
+		return
+	}
+	stateMux.Lock()
+	defer stateMux.Unlock()
+	workspaces[cache.Key(cache.Dir(env), dir)] = watchedWorkspace{dir: dir, env: env, seen: time.Now()}
+}
+
+// activeWorkspaces forgets workspaces and last lines idle for daemonIdleTimeout and
+// returns the workspaces left
+func activeWorkspaces() []watchedWorkspace {
+	stateMux.Lock()
+	defer stateMux.Unlock()
+	var active []watchedWorkspace
+	for key, workspace := range workspaces {
+		if time.Since(workspace.seen) > daemonIdleTimeout {
+			delete(workspaces, key)
+			continue
+		}
+		active = append(active, workspace)
+	}
+	for key, last := range lastLines {
+		if time.Since(last.rendered) > daemonIdleTimeout {
+			delete(lastLines, key)
+		}
+	}
+	return active
+}
+
+// refreshState keeps the cross-project usage aggregate and the git status of recently
+// rendered workspaces warm, and the cache pruned. A workspace is refreshed as soon as
+// its HEAD, index or refs change, and otherwise before its cached status expires.
+func refreshState() {
+	ticker := time.NewTicker(daemonRefreshInterval)
+	defer ticker.Stop()
+	for {
+		usage.CachedSummary(nil)
+		cache.Prune(nil)
+		for _, workspace := range activeWorkspaces() {
+			source.RefreshGitStatus(workspace.dir, workspace.env)
+		}
+		<-ticker.C
+	}
+}
@@ -0,0 +1,240 @@
+package daemon
+
+import (
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mrdavidaylward/ccstatus/internal/cache"
+	"github.com/mrdavidaylward/ccstatus/render"
+	"github.com/mrdavidaylward/ccstatus/schema"
+)
+
+// serveDaemon serves requests on a socket in a temp dir until the test ends. The
+// daemon's own home, Claude config and cache dirs are empty temp dirs, so renders
+// never scan the machine's transcripts.
+func serveDaemon(t *testing.T) string {
+	t.Helper()
+	socketPath := filepath.Join(t.TempDir(), "ccstatus.sock")
+	t.Setenv("CCSTATUS_SOCKET", socketPath)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("CLAUDE_CONFIG_DIR", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go handleDaemonConn(conn)
+		}
+	}()
+	return socketPath
+}
+
+// sendRequest sends a raw request to the daemon and returns its response
+func sendRequest(t *testing.T, socketPath string, request daemonRequest) daemonResponse {
+	t.Helper()
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err := json.NewEncoder(conn).Encode(request); err != nil {
+		t.Fatal(err)
+	}
+	var response daemonResponse
+	if err := json.NewDecoder(conn).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	return response
+}
+
+// TestRenderViaDaemon tests the client/daemon round trip
+func TestRenderViaDaemon(t *testing.T) {
+	serveDaemon(t)
+
+	input := []byte(`{"model":{"display_name":"Opus"},"workspace":{"current_dir":"/tmp"}}`)
+	got, warnings, ok := Render(input)
+	if !ok {
+		t.Fatal("Render() failed, want daemon response")
+	}
+	want, _, err := render.Render(input, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("Render() warnings = %v, want none", warnings)
+	}
+
+	if _, _, ok := Render([]byte(`{not json`)); ok {
+		t.Error("Render() with invalid input succeeded, want fallback")
+	}
+}
+
+// TestDaemonUsesClientEnv tests that config, theme, cache and commands come from the
+// environment the client sent rather than the daemon's own
+func TestDaemonUsesClientEnv(t *testing.T) {
+	socketPath := serveDaemon(t)
+	t.Setenv("CCSTATUS_THEME", "gruvbox")
+	daemonCache := os.Getenv("XDG_CACHE_HOME")
+
+	configHome, cacheHome := t.TempDir(), t.TempDir()
+	config := `{"widgets": ["model", {"name": "command", "options": {"command": "echo $CLIENT_VAR"}}]}`
+	if err := os.MkdirAll(filepath.Join(configHome, "ccstatus"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configHome, "ccstatus", "config.json"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	env := []string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + t.TempDir(),
+		"CLAUDE_CONFIG_DIR=" + t.TempDir(),
+		"XDG_CONFIG_HOME=" + configHome,
+		"XDG_CACHE_HOME=" + cacheHome,
+		"CCSTATUS_THEME=minimal",
+		"CLIENT_VAR=from-client",
+	}
+	input := json.RawMessage(`{"session_id":"s1","model":{"display_name":"Opus"},"workspace":{"current_dir":"/tmp"}}`)
+
+	response := sendRequest(t, socketPath, daemonRequest{Input: input, Env: env})
+
+	want, _, err := render.Render(input, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Output != want {
+		t.Errorf("daemon output = %q, want %q", response.Output, want)
+	}
+	if !strings.Contains(response.Output, "from-client") {
+		t.Errorf("daemon output = %q, want the command run with the client's env", response.Output)
+	}
+	if _, err := os.Stat(filepath.Join(cacheHome, "ccstatus")); err != nil {
+		t.Errorf("client cache not used: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(daemonCache, "ccstatus", "command")); err == nil {
+		t.Error("command output cached in the daemon's cache, want the client's")
+	}
+	if own, _, _ := render.Render(input, nil); response.Output == own {
+		t.Errorf("daemon output = %q, rendered with the daemon's own env", response.Output)
+	}
+}
+
+// TestRenderViaDaemonMissing tests fallback when no daemon is listening
+func TestRenderViaDaemonMissing(t *testing.T) {
+	t.Setenv("CCSTATUS_SOCKET", filepath.Join(t.TempDir(), "missing.sock"))
+
+	if _, _, ok := Render([]byte(`{}`)); ok {
+		t.Error("Render() without daemon succeeded, want fallback")
+	}
+}
+
+// blockingWidget counts its renders; every render after the first waits for release
+type blockingWidget struct {
+	renders *atomic.Int32
+	release chan struct{}
+}
+
+func (w blockingWidget) Collect(s *render.StatusLine, ctx *render.RenderContext, opts render.WidgetOptions) (render.TemplateFields, bool) {
+	n := w.renders.Add(1)
+	if n > 1 {
+		<-w.release
+	}
+	return render.TemplateFields{"n": int(n)}, true
+}
+
+func (blockingWidget) Render(s *render.StatusLine, fields render.TemplateFields, opts render.WidgetOptions, style render.WidgetStyle) {
+	s.AddFormattedWidget("blocking", opts, "render {n}", "", fields, style.Fg, style.Bg)
+}
+
+func (blockingWidget) DefaultStyle() render.WidgetStyle {
+	return render.WidgetStyle{}
+}
+
+// TestDaemonAnswersSlowRenderWithLastLine tests that a render running past the budget
+// is answered with the session's previous line and still finishes in the background
+func TestDaemonAnswersSlowRenderWithLastLine(t *testing.T) {
+	socketPath := serveDaemon(t)
+	widget := blockingWidget{renders: new(atomic.Int32), release: make(chan struct{})}
+	render.RegisterWidget("blocking", widget)
+
+	configHome := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(configHome, "ccstatus"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configHome, "ccstatus", "config.json"), []byte(`{"widgets": ["blocking"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	request := daemonRequest{
+		Input: json.RawMessage(`{"session_id":"slow","workspace":{"current_dir":"/tmp"}}`),
+		Env:   append(os.Environ(), "XDG_CONFIG_HOME="+configHome),
+	}
+
+	first := sendRequest(t, socketPath, request)
+	if !strings.Contains(first.Output, "render 1") {
+		t.Fatalf("first response = %q, want render 1", first.Output)
+	}
+
+	start := time.Now()
+	second := sendRequest(t, socketPath, request)
+	if second.Output != first.Output {
+		t.Errorf("slow render answered with %q, want the last line %q", second.Output, first.Output)
+	}
+	if elapsed := time.Since(start); elapsed >= daemonResponseTimeout {
+		t.Errorf("slow render answered after %v, want the last line within the budget", elapsed)
+	}
+
+	close(widget.release)
+	key := cache.Key(cache.Dir(schema.Env(request.Env)), "slow")
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(10 * time.Millisecond) {
+		stateMux.Lock()
+		last := lastLines[key].response.Output
+		stateMux.Unlock()
+		if strings.Contains(last, "render 2") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("last line = %q, want the background render 2 remembered", last)
+		}
+	}
+}
+
+// TestWatchWorkspace tests which workspaces the refresh loop keeps warm
+func TestWatchWorkspace(t *testing.T) {
+	dir := t.TempDir()
+	env := schema.Env{"XDG_CACHE_HOME=" + t.TempDir()}
+	watchWorkspace(dir, env)
+	watchWorkspace("~", env)
+	t.Cleanup(func() {
+		stateMux.Lock()
+		delete(workspaces, cache.Key(cache.Dir(env), dir))
+		stateMux.Unlock()
+	})
+
+	found := false
+	for _, workspace := range activeWorkspaces() {
+		if workspace.dir == "~" {
+			t.Error("activeWorkspaces() includes a relative workspace")
+		}
+		found = found || workspace.dir == dir
+	}
+	if !found {
+		t.Errorf("activeWorkspaces() lacks %s", dir)
+	}
+}
@@ -1,3 +1,3 @@
-module ccstatus
+module github.com/mrdavidaylward/ccstatus
 
 go 1.21
@@ -0,0 +1,243 @@
+// Package cache is the persistent cache shared by every ccstatus process. Claude
+// Code starts a new process per render, so in-memory caches never hit; entries
+// live on disk as one JSON file per key and are replaced atomically so
+// concurrent windows never read a partial write.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mrdavidaylward/ccstatus/internal/debug"
+	"github.com/mrdavidaylward/ccstatus/schema"
+)
+
+// Entry lifetimes; each data source picks its own TTL when reading
+const (
+	cacheMaxAge        = 24 * time.Hour
+	cachePruneInterval = time.Hour
+)
+
+// Lock tuning for read-modify-write updates of shared cache files
+const (
+	lockTimeout    = 500 * time.Millisecond
+	lockRetryDelay = 10 * time.Millisecond
+	lockStaleAfter = 10 * time.Second
+)
+
+// ErrLockTimeout is returned when another process holds a cache lock for too long
+var ErrLockTimeout = errors.New("timed out waiting for cache lock")
+
+// In-memory layer over the disk cache, enabled only in the long-lived daemon
+var (
+	memoryCache    map[string]diskCacheEntry
+	memoryCacheMux sync.RWMutex
+)
+
+// diskCacheEntry wraps a cached value with the time it was stored
+type diskCacheEntry struct {
+	Timestamp time.Time       `json:"timestamp"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// EnableMemory keeps cache entries in memory as well as on disk
+func EnableMemory() {
+	memoryCacheMux.Lock()
+	defer memoryCacheMux.Unlock()
+	if memoryCache == nil {
+		memoryCache = make(map[string]diskCacheEntry)
+	}
+}
+
+// Dir returns the ccstatus cache directory under XDG_CACHE_HOME (default ~/.cache)
+func Dir(env schema.Env) string {
+	cacheHome := env.Getenv("XDG_CACHE_HOME")
+	if cacheHome == "" {
+		homeDir, err := env.HomeDir()
+		if err != nil {
+			return ""
+		}
+		cacheHome = filepath.Join(homeDir, ".cache")
+	}
+	return filepath.Join(cacheHome, "ccstatus")
+}
+
+// Key hashes the parts (session IDs, paths, ...) into a filesystem-safe key
+func Key(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:12])
+}
+
+// cachePath returns the file backing a cache entry, or "" when caching is unavailable
+func cachePath(env schema.Env, namespace, key string) string {
+	cacheDir := Dir(env)
+	if cacheDir == "" {
+		return ""
+	}
+	return filepath.Join(cacheDir, namespace, key+".json")
+}
+
+// Get decodes a fresh cache entry from env's cache into out and reports whether it was found
+func Get(env schema.Env, namespace, key string, ttl time.Duration, out interface{}) bool {
+	path := cachePath(env, namespace, key)
+	if path == "" {
+		return false
+	}
+
+	memoryCacheMux.RLock()
+	entry, ok := memoryCache[path]
+	memoryCacheMux.RUnlock()
+
+	if !ok {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return false
+		}
+		if err := json.Unmarshal(content, &entry); err != nil {
+			return false
+		}
+	}
+	age := time.Since(entry.Timestamp)
+	if age < 0 || age >= ttl {
+		return false
+	}
+	if err := json.Unmarshal(entry.Data, out); err != nil {
+		return false
+	}
+
+	debug.Log("Cache hit %s/%s (age: %v)", namespace, key, age)
+	return true
+}
+
+// Put stores value under the key in env's cache, replacing any previous entry atomically
+func Put(env schema.Env, namespace, key string, value interface{}) error {
+	path := cachePath(env, namespace, key)
+	if path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	entry := diskCacheEntry{Timestamp: time.Now(), Data: data}
+
+	memoryCacheMux.Lock()
+	if memoryCache != nil {
+		memoryCache[path] = entry
+	}
+	memoryCacheMux.Unlock()
+
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(path, content)
+}
+
+// WriteFileAtomic writes to a temp file in the same directory, then renames it into place
+func WriteFileAtomic(path string, content []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(content); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	// Rename is atomic on Unix systems
+	return os.Rename(tmpFile.Name(), path)
+}
+
+// AcquireLock takes an exclusive lock file next to path and returns its release function.
+// Lock files left behind by a crashed process are broken after lockStaleAfter.
+func AcquireLock(path string) (func(), error) {
+	lockPath := path + ".lock"
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0755); err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			fmt.Fprintf(file, "%d\n", os.Getpid())
+			file.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > lockStaleAfter {
+			debug.Log("Breaking stale lock %s", lockPath)
+			os.Remove(lockPath)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, ErrLockTimeout
+		}
+		time.Sleep(lockRetryDelay)
+	}
+}
+
+// Prune removes entries in env's cache older than cacheMaxAge, at most once per cachePruneInterval
+func Prune(env schema.Env) {
+	cacheDir := Dir(env)
+	if cacheDir == "" {
+		return
+	}
+
+	marker := filepath.Join(cacheDir, ".pruned")
+	if info, err := os.Stat(marker); err == nil && time.Since(info.ModTime()) < cachePruneInterval {
+		return
+	}
+	if err := WriteFileAtomic(marker, nil); err != nil {
+		return
+	}
+
+	cutoff := time.Now().Add(-cacheMaxAge)
+
+	memoryCacheMux.Lock()
+	for path, entry := range memoryCache {
+		if entry.Timestamp.Before(cutoff) {
+			delete(memoryCache, path)
+		}
+	}
+	memoryCacheMux.Unlock()
+
+	filepath.WalkDir(cacheDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".json") {
+			return nil
+		}
+		// The usage index is long-lived state, not a TTL entry
+		if filepath.Dir(path) == cacheDir {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && info.ModTime().Before(cutoff) {
+			debug.Log("Pruning cache entry %s", path)
+			os.Remove(path)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestCacheRoundTrip tests storing and reading entries with TTLs
+func TestCacheRoundTrip(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	type entry struct {
+		SessionTokens int
+		Messages      int
+		SessionID     string
+	}
+	want := entry{SessionTokens: 1234, Messages: 5, SessionID: "abc"}
+	key := Key("ccusage", "abc")
+	if err := Put(nil, "ccusage", key, want); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	var got entry
+	if !Get(nil, "ccusage", key, time.Minute, &got) {
+		t.Fatal("Get() miss, want hit")
+	}
+	if got != want {
+		t.Errorf("Get() = %+v, want %+v", got, want)
+	}
+
+	if Get(nil, "ccusage", key, 0, &got) {
+		t.Error("Get() with zero TTL hit, want miss")
+	}
+	if Get(nil, "ccusage", Key("ccusage", "other"), time.Minute, &got) {
+		t.Error("Get() for another session hit, want miss")
+	}
+}
+
+// TestCacheKey tests that keys are stable and distinguish their parts
+func TestCacheKey(t *testing.T) {
+	if Key("a", "bc") != Key("a", "bc") {
+		t.Error("Key() is not stable")
+	}
+	if Key("a", "bc") == Key("ab", "c") {
+		t.Error("Key() collides when parts shift")
+	}
+}
+
+// TestAcquireLock tests exclusive locking and stale lock recovery
+func TestAcquireLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage-index.json")
+
+	release, err := AcquireLock(path)
+	if err != nil {
+		t.Fatalf("AcquireLock() error = %v", err)
+	}
+	if _, err := AcquireLock(path); err != ErrLockTimeout {
+		t.Errorf("second AcquireLock() error = %v, want %v", err, ErrLockTimeout)
+	}
+	release()
+
+	release, err = AcquireLock(path)
+	if err != nil {
+		t.Fatalf("AcquireLock() after release error = %v", err)
+	}
+
+	// Simulate a lock left behind by a crashed process
+	old := time.Now().Add(-2 * lockStaleAfter)
+	if err := os.Chtimes(path+".lock", old, old); err != nil {
+		t.Fatal(err)
+	}
+	release2, err := AcquireLock(path)
+	if err != nil {
+		t.Fatalf("AcquireLock() over stale lock error = %v", err)
+	}
+	release2()
+	release()
+}
@@ -0,0 +1,17 @@
+// Package debug logs diagnostics when CCSTATUS_DEBUG=1.
+package debug
+
+import (
+	"log"
+	"os"
+)
+
+// Enabled reports whether debug logging is on
+var Enabled = os.Getenv("CCSTATUS_DEBUG") == "1"
+
+// Log writes a debug message to stderr when debugging is enabled
+func Log(format string, args ...interface{}) {
+	if Enabled {
+		log.Printf("[DEBUG] "+format, args...)
+	}
+}
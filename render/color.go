@@ -0,0 +1,182 @@
+package render
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ColorKind tells which palette a Color comes from
+type ColorKind uint8
+
+const (
+	ColorDefault ColorKind = iota // Terminal default, renders as no escape sequence
+	Color16                       // Basic palette: 0-7 normal, 8-15 bright
+	Color256                      // xterm 256-color palette index
+	ColorRGB                      // 24-bit truecolor
+)
+
+// Color is a terminal color that can be rendered as foreground or background
+type Color struct {
+	Kind    ColorKind
+	Index   uint8 // Palette index for Color16 and Color256
+	R, G, B uint8 // Components for ColorRGB
+}
+
+// NewColor16 returns a basic palette color (0-7 normal, 8-15 bright)
+func NewColor16(index int) Color {
+	return Color{Kind: Color16, Index: uint8(index)}
+}
+
+// NewColor256 returns an xterm 256-color palette color
+func NewColor256(index int) Color {
+	return Color{Kind: Color256, Index: uint8(index)}
+}
+
+// NewColorRGB returns a truecolor color
+func NewColorRGB(r, g, b int) Color {
+	return Color{Kind: ColorRGB, R: uint8(r), G: uint8(g), B: uint8(b)}
+}
+
+// Fg renders the color as a foreground escape sequence
+func (c Color) Fg() string {
+	return c.render(false)
+}
+
+// Bg renders the color as a background escape sequence
+func (c Color) Bg() string {
+	return c.render(true)
+}
+
+func (c Color) render(background bool) string {
+	switch c.Kind {
+	case Color16:
+		code := 30 + int(c.Index)
+		if c.Index >= 8 {
+			code = 90 + int(c.Index) - 8
+		}
+		if background {
+			code += 10
+		}
+		return fmt.Sprintf("\033[%dm", code)
+	case Color256:
+		if background {
+			return fmt.Sprintf("\033[48;5;%dm", c.Index)
+		}
+		return fmt.Sprintf("\033[38;5;%dm", c.Index)
+	case ColorRGB:
+		if background {
+			return trueColorBg(int(c.R), int(c.G), int(c.B))
+		}
+		return trueColor(int(c.R), int(c.G), int(c.B))
+	}
+	return ""
+}
+
+// namedColors maps color names to their basic palette index
+var namedColors = map[string]int{
+	"black":          0,
+	"red":            1,
+	"green":          2,
+	"yellow":         3,
+	"blue":           4,
+	"magenta":        5,
+	"cyan":           6,
+	"white":          7,
+	"bright_black":   8,
+	"bright_red":     9,
+	"bright_green":   10,
+	"bright_yellow":  11,
+	"bright_blue":    12,
+	"bright_magenta": 13,
+	"bright_cyan":    14,
+	"bright_white":   15,
+}
+
+// parseColorSpec parses a color name, 256-color index or hex value.
+// Accepted forms: "" or "default" (no color), names like "red" or "bright_blue",
+// 256-color indexes like "208", and hex like "#fe8019" or "#f80".
+func parseColorSpec(spec string) (Color, error) {
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	switch spec {
+	case "", "default", "none":
+		return Color{}, nil
+	}
+
+	if strings.HasPrefix(spec, "#") {
+		r, g, b, err := parseHexColor(spec)
+		if err != nil {
+			return Color{}, err
+		}
+		return NewColorRGB(r, g, b), nil
+	}
+
+	if index, err := strconv.Atoi(spec); err == nil {
+		if index < 0 || index > 255 {
+			return Color{}, fmt.Errorf("color index %d out of range 0-255", index)
+		}
+		return NewColor256(index), nil
+	}
+
+	name := strings.NewReplacer("-", "_", " ", "_").Replace(spec)
+	if !strings.Contains(name, "_") && strings.HasPrefix(name, "bright") {
+		name = "bright_" + strings.TrimPrefix(name, "bright")
+	}
+	index, ok := namedColors[name]
+	if !ok {
+		return Color{}, fmt.Errorf("unknown color %q", spec)
+	}
+	return NewColor16(index), nil
+}
+
+// parseHexColor parses #rgb or #rrggbb
+func parseHexColor(spec string) (r, g, b int, err error) {
+	hex := strings.TrimPrefix(spec, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return 0, 0, 0, fmt.Errorf("invalid hex color %q", spec)
+	}
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid hex color %q", spec)
+	}
+	return int(value >> 16 & 0xff), int(value >> 8 & 0xff), int(value & 0xff), nil
+}
+
+// colorFromANSI decodes a single-color SGR escape sequence such as "\033[44m",
+// "\033[48;5;208m" or "\033[38;2;60;56;54m"; ok is false for anything else
+func colorFromANSI(seq string) (c Color, ok bool) {
+	if !strings.HasPrefix(seq, "\033[") || !strings.HasSuffix(seq, "m") {
+		return Color{}, false
+	}
+	var params []int
+	for _, field := range strings.Split(seq[2:len(seq)-1], ";") {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 || n > 255 {
+			return Color{}, false
+		}
+		params = append(params, n)
+	}
+
+	switch {
+	case len(params) == 1:
+		code := params[0]
+		switch {
+		case code >= 30 && code <= 37:
+			return NewColor16(code - 30), true
+		case code >= 40 && code <= 47:
+			return NewColor16(code - 40), true
+		case code >= 90 && code <= 97:
+			return NewColor16(code - 90 + 8), true
+		case code >= 100 && code <= 107:
+			return NewColor16(code - 100 + 8), true
+		}
+	case len(params) == 3 && (params[0] == 38 || params[0] == 48) && params[1] == 5:
+		return NewColor256(params[2]), true
+	case len(params) == 5 && (params[0] == 38 || params[0] == 48) && params[1] == 2:
+		return NewColorRGB(params[2], params[3], params[4]), true
+	}
+	return Color{}, false
+}
@@ -0,0 +1,76 @@
+package render
+
+import (
+	"testing"
+)
+
+// TestParseColorSpec tests parsing names, indexes and hex into colors
+func TestParseColorSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    Color
+		wantErr bool
+	}{
+		{name: "default", spec: "", want: Color{}},
+		{name: "name", spec: "magenta", want: NewColor16(5)},
+		{name: "bright name", spec: "Bright-Cyan", want: NewColor16(14)},
+		{name: "256 index", spec: "208", want: NewColor256(208)},
+		{name: "hex", spec: "#fe8019", want: NewColorRGB(254, 128, 25)},
+		{name: "out of range", spec: "256", wantErr: true},
+		{name: "unknown", spec: "chartreuse", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseColorSpec(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseColorSpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseColorSpec() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestColorRender tests foreground/background rendering and decoding it back
+func TestColorRender(t *testing.T) {
+	tests := []struct {
+		name   string
+		color  Color
+		wantFg string
+		wantBg string
+	}{
+		{name: "normal", color: NewColor16(4), wantFg: ColorBlue, wantBg: BgBlue},
+		{name: "bright", color: NewColor16(13), wantFg: ColorBrightMagenta, wantBg: BgBrightMagenta},
+		{name: "256", color: NewColor256(236), wantFg: "\033[38;5;236m", wantBg: "\033[48;5;236m"},
+		{name: "rgb", color: NewColorRGB(60, 56, 54), wantFg: trueColor(60, 56, 54), wantBg: trueColorBg(60, 56, 54)},
+		{name: "default", color: Color{}, wantFg: "", wantBg: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.color.Fg(); got != tt.wantFg {
+				t.Errorf("Fg() = %q, want %q", got, tt.wantFg)
+			}
+			if got := tt.color.Bg(); got != tt.wantBg {
+				t.Errorf("Bg() = %q, want %q", got, tt.wantBg)
+			}
+			if tt.color.Kind == ColorDefault {
+				return
+			}
+			for _, seq := range []string{tt.wantFg, tt.wantBg} {
+				if got, ok := colorFromANSI(seq); !ok || got != tt.color {
+					t.Errorf("colorFromANSI(%q) = %+v, %v, want %+v", seq, got, ok, tt.color)
+				}
+			}
+		})
+	}
+
+	for _, seq := range []string{"", ColorReset, ColorBold, "\033[49m", "\033[38;5;300m", "plain"} {
+		if _, ok := colorFromANSI(seq); ok {
+			t.Errorf("colorFromANSI(%q) ok, want not a color", seq)
+		}
+	}
+}
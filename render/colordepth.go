@@ -0,0 +1,194 @@
+package render
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/mrdavidaylward/ccstatus/internal/debug"
+)
+
+// ColorDepth is the number of colors the terminal can display
+type ColorDepth int
+
+const (
+	DepthTrueColor ColorDepth = iota // 24-bit; colors are passed through unchanged
+	Depth256                         // xterm 256-color palette
+	Depth16                          // Basic 16-color palette
+	DepthNone                        // No colors at all (NO_COLOR, dumb terminals)
+)
+
+// colorDepthNames maps config values to depths
+var colorDepthNames = map[string]ColorDepth{
+	"truecolor": DepthTrueColor,
+	"24bit":     DepthTrueColor,
+	"256":       Depth256,
+	"16":        Depth16,
+	"none":      DepthNone,
+}
+
+// palette16 holds the xterm default RGB values of the basic palette
+var palette16 = [16][3]int{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// cubeLevels are the component values of the 6x6x6 color cube in the 256-color palette
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+// detectColorDepth picks the color depth from the config override, NO_COLOR, COLORTERM and TERM
+func detectColorDepth(config Config, getenv func(string) string) ColorDepth {
+	if setting := strings.ToLower(config.ColorDepth); setting != "" && setting != "auto" {
+		if depth, ok := colorDepthNames[setting]; ok {
+			return depth
+		}
+		debug.Log("Unknown color_depth %q, detecting instead", config.ColorDepth)
+	}
+
+	// https://no-color.org: any non-empty value disables color
+	if getenv("NO_COLOR") != "" {
+		return DepthNone
+	}
+
+	switch strings.ToLower(getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return DepthTrueColor
+	}
+
+	term := strings.ToLower(getenv("TERM"))
+	switch {
+	case term == "":
+		return DepthTrueColor // Not started from a terminal we can identify; keep theme colors as-is
+	case term == "dumb":
+		return DepthNone
+	case strings.HasSuffix(term, "-direct") || strings.Contains(term, "truecolor") || strings.Contains(term, "24bit"):
+		return DepthTrueColor
+	case strings.Contains(term, "256color"):
+		return Depth256
+	}
+	return Depth16
+}
+
+// RGB returns the color's approximate red, green and blue components
+func (c Color) RGB() (r, g, b int) {
+	switch c.Kind {
+	case ColorRGB:
+		return int(c.R), int(c.G), int(c.B)
+	case Color16:
+		rgb := palette16[c.Index&15]
+		return rgb[0], rgb[1], rgb[2]
+	case Color256:
+		index := int(c.Index)
+		switch {
+		case index < 16:
+			rgb := palette16[index]
+			return rgb[0], rgb[1], rgb[2]
+		case index < 232:
+			index -= 16
+			return cubeLevels[index/36], cubeLevels[index/6%6], cubeLevels[index%6]
+		default:
+			gray := 8 + (index-232)*10
+			return gray, gray, gray
+		}
+	}
+	return 0, 0, 0
+}
+
+// Downsample converts the color to the nearest one available at depth
+func (c Color) Downsample(depth ColorDepth) Color {
+	switch {
+	case c.Kind == ColorDefault || depth == DepthTrueColor:
+		return c
+	case depth == DepthNone:
+		return Color{}
+	case depth == Depth256 && c.Kind == ColorRGB:
+		return nearestColor256(c.RGB())
+	case depth == Depth16 && c.Kind == Color256 && c.Index < 16:
+		return NewColor16(int(c.Index))
+	case depth == Depth16 && c.Kind != Color16:
+		return nearestColor16(c.RGB())
+	}
+	return c
+}
+
+// nearestColor256 finds the closest cube or grayscale entry of the 256-color palette
+func nearestColor256(r, g, b int) Color {
+	nearestLevel := func(v int) int {
+		best := 0
+		for i, level := range cubeLevels {
+			if abs(v-level) < abs(v-cubeLevels[best]) {
+				best = i
+			}
+		}
+		return best
+	}
+	ri, gi, bi := nearestLevel(r), nearestLevel(g), nearestLevel(b)
+	cube := NewColor256(16 + 36*ri + 6*gi + bi)
+
+	grayIndex := ((r+g+b)/3 - 8 + 5) / 10
+	if grayIndex < 0 {
+		grayIndex = 0
+	} else if grayIndex > 23 {
+		grayIndex = 23
+	}
+	gray := NewColor256(232 + grayIndex)
+
+	if colorDistance(gray, r, g, b) < colorDistance(cube, r, g, b) {
+		return gray
+	}
+	return cube
+}
+
+// nearestColor16 finds the closest basic palette color
+func nearestColor16(r, g, b int) Color {
+	best := NewColor16(0)
+	for i := 1; i < len(palette16); i++ {
+		if candidate := NewColor16(i); colorDistance(candidate, r, g, b) < colorDistance(best, r, g, b) {
+			best = candidate
+		}
+	}
+	return best
+}
+
+// colorDistance is the squared RGB distance between c and the given components
+func colorDistance(c Color, r, g, b int) int {
+	cr, cg, cb := c.RGB()
+	return (cr-r)*(cr-r) + (cg-g)*(cg-g) + (cb-b)*(cb-b)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+var sgrPattern = regexp.MustCompile("\033\\[[0-9;]*m")
+
+// adaptColors rewrites every color escape in s for the given depth, or strips them all for DepthNone
+func adaptColors(s string, depth ColorDepth) string {
+	if depth == DepthTrueColor {
+		return s
+	}
+	return sgrPattern.ReplaceAllStringFunc(s, func(seq string) string {
+		if depth == DepthNone {
+			return ""
+		}
+		c, ok := colorFromANSI(seq)
+		if !ok {
+			return seq // Resets and attributes pass through
+		}
+		c = c.Downsample(depth)
+		if isBackgroundSGR(seq) {
+			return c.Bg()
+		}
+		return c.Fg()
+	})
+}
+
+// isBackgroundSGR reports whether a color escape sets the background
+func isBackgroundSGR(seq string) bool {
+	params := strings.TrimPrefix(seq, "\033[")
+	return strings.HasPrefix(params, "4") || strings.HasPrefix(params, "10")
+}
@@ -0,0 +1,79 @@
+package render
+
+import (
+	"testing"
+)
+
+// TestDetectColorDepth tests config overrides and environment detection
+func TestDetectColorDepth(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		env    map[string]string
+		want   ColorDepth
+	}{
+		{name: "config override", config: Config{ColorDepth: "16"}, env: map[string]string{"COLORTERM": "truecolor"}, want: Depth16},
+		{name: "config auto", config: Config{ColorDepth: "auto"}, env: map[string]string{"TERM": "xterm-256color"}, want: Depth256},
+		{name: "no color", env: map[string]string{"NO_COLOR": "1", "COLORTERM": "truecolor"}, want: DepthNone},
+		{name: "colorterm", env: map[string]string{"COLORTERM": "24bit", "TERM": "screen"}, want: DepthTrueColor},
+		{name: "tmux 256", env: map[string]string{"TERM": "tmux-256color"}, want: Depth256},
+		{name: "direct color term", env: map[string]string{"TERM": "xterm-direct"}, want: DepthTrueColor},
+		{name: "basic term", env: map[string]string{"TERM": "xterm"}, want: Depth16},
+		{name: "dumb", env: map[string]string{"TERM": "dumb"}, want: DepthNone},
+		{name: "unknown", env: map[string]string{}, want: DepthTrueColor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(key string) string { return tt.env[key] }
+			if got := detectColorDepth(tt.config, getenv); got != tt.want {
+				t.Errorf("detectColorDepth() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestColorDownsample tests nearest-color matching at each depth
+func TestColorDownsample(t *testing.T) {
+	tests := []struct {
+		name  string
+		color Color
+		depth ColorDepth
+		want  Color
+	}{
+		{name: "truecolor unchanged", color: NewColorRGB(254, 128, 25), depth: DepthTrueColor, want: NewColorRGB(254, 128, 25)},
+		{name: "rgb to cube", color: NewColorRGB(254, 128, 25), depth: Depth256, want: NewColor256(208)},
+		{name: "rgb to grayscale", color: NewColorRGB(60, 56, 54), depth: Depth256, want: NewColor256(237)},
+		{name: "rgb to 16", color: NewColorRGB(251, 73, 52), depth: Depth16, want: NewColor16(9)},
+		{name: "256 to 16", color: NewColor256(22), depth: Depth16, want: NewColor16(0)},
+		{name: "low 256 index", color: NewColor256(12), depth: Depth16, want: NewColor16(12)},
+		{name: "16 unchanged", color: NewColor16(4), depth: Depth256, want: NewColor16(4)},
+		{name: "none", color: NewColor16(4), depth: DepthNone, want: Color{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.color.Downsample(tt.depth); got != tt.want {
+				t.Errorf("Downsample() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestAdaptColors tests rewriting rendered output for the terminal's depth
+func TestAdaptColors(t *testing.T) {
+	line := trueColorBg(60, 56, 54) + trueColor(254, 128, 25) + " main " + ColorReset
+
+	if got := adaptColors(line, DepthTrueColor); got != line {
+		t.Errorf("adaptColors(truecolor) = %q, want unchanged", got)
+	}
+	if got, want := adaptColors(line, Depth256), "\033[48;5;237m\033[38;5;208m main "+ColorReset; got != want {
+		t.Errorf("adaptColors(256) = %q, want %q", got, want)
+	}
+	if got, want := adaptColors(line, Depth16), BgBlack+ColorYellow+" main "+ColorReset; got != want {
+		t.Errorf("adaptColors(16) = %q, want %q", got, want)
+	}
+	if got := adaptColors(line, DepthNone); got != " main " {
+		t.Errorf("adaptColors(none) = %q, want plain text", got)
+	}
+}
@@ -0,0 +1,61 @@
+package render
+
+import (
+	"time"
+
+	"github.com/mrdavidaylward/ccstatus/internal/debug"
+	"github.com/mrdavidaylward/ccstatus/source"
+)
+
+func init() {
+	RegisterWidget("command", commandWidget{})
+}
+
+// commandWidget shows the output of its "command" option
+type commandWidget struct{}
+
+// Collect runs the command (or reuses its cached output) and exposes the result as fields
+func (commandWidget) Collect(s *StatusLine, ctx *RenderContext, opts WidgetOptions) (TemplateFields, bool) {
+	command := opts.String("command", "")
+	if command == "" {
+		debug.Log("Command widget has no command, skipping")
+		return nil, false
+	}
+
+	timeout := time.Duration(opts.Int("timeout", int(source.DefaultCommandTimeout/time.Millisecond))) * time.Millisecond
+	ttl := time.Duration(opts.Int("cache", int(source.DefaultCommandCacheTTL/time.Second))) * time.Second
+
+	result := source.CachedCommand(command, ctx.Input, timeout, ttl, s.Env)
+	if result.Text == "" {
+		return nil, false
+	}
+	return TemplateFields{
+		"text":     result.Text,
+		"compact":  result.Compact,
+		"fg":       result.Fg,
+		"bg":       result.Bg,
+		"priority": result.Priority,
+	}, true
+}
+
+// Render adds the command's text; its own colors win over the "fg" and "bg" options
+func (commandWidget) Render(s *StatusLine, fields TemplateFields, opts WidgetOptions, style WidgetStyle) {
+	text, _ := fields["text"].(string)
+	compact, _ := fields["compact"].(string)
+	fg, _ := fields["fg"].(string)
+	bg, _ := fields["bg"].(string)
+	priority, _ := fields["priority"].(int)
+
+	color := resolveColor(opts.String("fg", ""), false, style.Fg)
+	bgColor := resolveColor(opts.String("bg", ""), true, style.Bg)
+	color = resolveColor(fg, false, color)
+	bgColor = resolveColor(bg, true, bgColor)
+
+	s.AddWidgetCompact("command", text, compact, color, bgColor)
+	s.Widgets[len(s.Widgets)-1].Priority = priority
+}
+
+// DefaultStyle falls back to the tokens colors
+func (commandWidget) DefaultStyle() WidgetStyle {
+	return WidgetStyle{Fallback: "tokens"}
+}
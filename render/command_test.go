@@ -0,0 +1,50 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mrdavidaylward/ccstatus/schema"
+)
+
+// TestCommandWidget tests rendering, caching, priority and omission on failure
+func TestCommandWidget(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	counter := filepath.Join(t.TempDir(), "runs")
+	command := `echo x >> ` + counter + `; echo '{"text": "deploy", "fg": "red", "priority": 77}'`
+
+	ctx := &RenderContext{Input: schema.StatusLineInput{SessionID: "s1"}}
+	opts := WidgetOptions{"command": command, "cache": float64(60)}
+
+	for i := 0; i < 2; i++ {
+		s := &StatusLine{Theme: themes["powerline"]}
+		s.buildWidget("command", ctx, opts)
+		if len(s.Widgets) != 1 {
+			t.Fatalf("got %d widgets, want 1", len(s.Widgets))
+		}
+		w := s.Widgets[0]
+		if w.Content != "deploy" || w.Color != ColorRed || w.Priority != 77 {
+			t.Errorf("widget = %+v", w)
+		}
+	}
+
+	runs, _ := os.ReadFile(counter)
+	if n := strings.Count(string(runs), "x"); n != 1 {
+		t.Errorf("command ran %d times, want 1 (second render cached)", n)
+	}
+
+	for _, opts := range []WidgetOptions{
+		{},
+		{"command": "exit 1", "cache": float64(0)},
+		{"command": "echo", "cache": float64(0)},
+		{"command": "sleep 5", "timeout": float64(20), "cache": float64(0)},
+	} {
+		s := &StatusLine{Theme: themes["powerline"]}
+		s.buildWidget("command", ctx, opts)
+		if len(s.Widgets) != 0 {
+			t.Errorf("options %v: got widget %q, want none", opts, s.Widgets[0].Content)
+		}
+	}
+}
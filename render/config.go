@@ -0,0 +1,191 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mrdavidaylward/ccstatus/internal/debug"
+	"github.com/mrdavidaylward/ccstatus/schema"
+)
+
+// Config is the declarative status line configuration loaded from config.json
+type Config struct {
+	Theme      string            `json:"theme,omitempty"`
+	Pricing    string            `json:"pricing,omitempty"` // Path to a pricing override file
+	Widgets    []WidgetConfig    `json:"widgets,omitempty"`
+	Lines      [][]WidgetConfig  `json:"lines,omitempty"`       // Multi-line layout; takes precedence over Widgets
+	Width      int               `json:"width,omitempty"`       // Columns to fit into; defaults to COLUMNS
+	ColorDepth string            `json:"color_depth,omitempty"` // truecolor, 256, 16, none or auto (default)
+	IconSet    string            `json:"icon_set,omitempty"`    // default, nerd, emoji, unicode or ascii
+	Icons      map[string]string `json:"icons,omitempty"`       // Per-icon overrides
+}
+
+// WidgetConfig selects a widget by name and carries its per-widget options
+type WidgetConfig struct {
+	Name    string        `json:"name"`
+	Enabled *bool         `json:"enabled,omitempty"`
+	Options WidgetOptions `json:"options,omitempty"`
+}
+
+// WidgetOptions holds free-form per-widget settings from the config file
+type WidgetOptions map[string]interface{}
+
+// defaultWidgetOrder is the layout used when the config does not list widgets
+var defaultWidgetOrder = []string{
+	"user",
+	"path",
+	"git",
+	"model",
+	"percent",
+	"weekly",
+	"tokens",
+	"cost",
+	"messages",
+	"efficiency",
+	"compaction",
+	"timer",
+	"reset",
+}
+
+// UnmarshalJSON accepts either a bare widget name or a full widget object
+func (w *WidgetConfig) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*w = WidgetConfig{Name: name}
+		return nil
+	}
+
+	type widgetConfigAlias WidgetConfig
+	var alias widgetConfigAlias
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return err
+	}
+	if alias.Name == "" {
+		return fmt.Errorf("widget entry is missing a name")
+	}
+	*w = WidgetConfig(alias)
+	return nil
+}
+
+// IsEnabled reports whether the widget should be rendered (default true)
+func (w WidgetConfig) IsEnabled() bool {
+	return w.Enabled == nil || *w.Enabled
+}
+
+// Int returns an integer option, falling back to def when unset or invalid
+func (o WidgetOptions) Int(key string, def int) int {
+	// encoding/json decodes all numbers into float64
+	if v, ok := o[key].(float64); ok {
+		return int(v)
+	}
+	return def
+}
+
+// String returns a string option, falling back to def when unset or invalid
+func (o WidgetOptions) String(key, def string) string {
+	if v, ok := o[key].(string); ok {
+		return v
+	}
+	return def
+}
+
+// Bool returns a boolean option, falling back to def when unset or invalid
+func (o WidgetOptions) Bool(key string, def bool) bool {
+	if v, ok := o[key].(bool); ok {
+		return v
+	}
+	return def
+}
+
+// WidgetLines returns the configured rows of widgets, one per output line
+func (c Config) WidgetLines() [][]WidgetConfig {
+	if len(c.Lines) > 0 {
+		return c.Lines
+	}
+	if len(c.Widgets) > 0 {
+		return [][]WidgetConfig{c.Widgets}
+	}
+	return [][]WidgetConfig{defaultWidgetConfigs()}
+}
+
+// defaultConfig returns the built-in configuration
+func defaultConfig() Config {
+	return Config{Widgets: defaultWidgetConfigs()}
+}
+
+// defaultWidgetConfigs returns every built-in widget in the default order
+func defaultWidgetConfigs() []WidgetConfig {
+	widgets := make([]WidgetConfig, 0, len(defaultWidgetOrder))
+	for _, name := range defaultWidgetOrder {
+		widgets = append(widgets, WidgetConfig{Name: name})
+	}
+	return widgets
+}
+
+// getConfigDir returns the ccstatus config directory under XDG_CONFIG_HOME (default ~/.config)
+func getConfigDir(env schema.Env) string {
+	configHome := env.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		homeDir, err := env.HomeDir()
+		if err != nil {
+			return ""
+		}
+		configHome = filepath.Join(homeDir, ".config")
+	}
+	return filepath.Join(configHome, "ccstatus")
+}
+
+// getConfigPath returns the config file location, honoring CCSTATUS_CONFIG
+func getConfigPath(env schema.Env) string {
+	if path := env.Getenv("CCSTATUS_CONFIG"); path != "" {
+		return path
+	}
+
+	configDir := getConfigDir(env)
+	if configDir == "" {
+		return ""
+	}
+	return filepath.Join(configDir, "config.json")
+}
+
+// LoadConfig reads the config file at path; a missing file yields the default config
+func LoadConfig(path string) (Config, error) {
+	if path == "" {
+		return defaultConfig(), nil
+	}
+
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		debug.Log("No config file at %s, using defaults", path)
+		return defaultConfig(), nil
+	}
+	if err != nil {
+		return defaultConfig(), err
+	}
+
+	var config Config
+	if err := json.Unmarshal(content, &config); err != nil {
+		return defaultConfig(), fmt.Errorf("%s: %w", path, err)
+	}
+
+	if len(config.Widgets) == 0 && len(config.Lines) == 0 {
+		config.Widgets = defaultWidgetConfigs()
+	}
+
+	debug.Log("Loaded config from %s (%d widgets, %d lines)", path, len(config.Widgets), len(config.Lines))
+	return config, nil
+}
+
+// getPricingPath returns the pricing override file, from config or the config directory
+func getPricingPath(config Config, env schema.Env) string {
+	if config.Pricing != "" {
+		return config.Pricing
+	}
+	configDir := getConfigDir(env)
+	if configDir == "" {
+		return ""
+	}
+	return filepath.Join(configDir, "pricing.json")
+}
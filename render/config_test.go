@@ -0,0 +1,227 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mrdavidaylward/ccstatus/schema"
+)
+
+// TestLoadConfig tests config file parsing and defaults
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		wantTheme   string
+		wantWidgets []string
+		wantErr     bool
+	}{
+		{
+			name:        "missing file",
+			content:     "",
+			wantWidgets: defaultWidgetOrder,
+		},
+		{
+			name:        "theme only",
+			content:     `{"theme": "gruvbox"}`,
+			wantTheme:   "gruvbox",
+			wantWidgets: defaultWidgetOrder,
+		},
+		{
+			name:        "mixed widget entries",
+			content:     `{"widgets": ["model", {"name": "path", "options": {"max_length": 20}}, {"name": "git", "enabled": false}]}`,
+			wantWidgets: []string{"model", "path", "git"},
+		},
+		{
+			name:        "widget without name",
+			content:     `{"widgets": [{"enabled": true}]}`,
+			wantWidgets: defaultWidgetOrder,
+			wantErr:     true,
+		},
+		{
+			name:        "invalid json",
+			content:     `{"theme": `,
+			wantWidgets: defaultWidgetOrder,
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			if tt.content != "" {
+				if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := LoadConfig(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.Theme != tt.wantTheme {
+				t.Errorf("LoadConfig() theme = %v, want %v", got.Theme, tt.wantTheme)
+			}
+			if len(got.Widgets) != len(tt.wantWidgets) {
+				t.Fatalf("LoadConfig() widgets = %d, want %d", len(got.Widgets), len(tt.wantWidgets))
+			}
+			for i, name := range tt.wantWidgets {
+				if got.Widgets[i].Name != name {
+					t.Errorf("LoadConfig() widget[%d] = %v, want %v", i, got.Widgets[i].Name, name)
+				}
+			}
+		})
+	}
+}
+
+// TestWidgetOptions tests typed option lookups with defaults
+func TestWidgetOptions(t *testing.T) {
+	opts := WidgetOptions{"max_length": float64(20), "label": "x", "show_host": false}
+
+	if got := opts.Int("max_length", 30); got != 20 {
+		t.Errorf("Int() = %v, want 20", got)
+	}
+	if got := opts.Int("missing", 30); got != 30 {
+		t.Errorf("Int() default = %v, want 30", got)
+	}
+	if got := opts.String("label", "y"); got != "x" {
+		t.Errorf("String() = %v, want x", got)
+	}
+	if got := opts.Bool("show_host", true); got != false {
+		t.Errorf("Bool() = %v, want false", got)
+	}
+	if got := opts.Int("label", 5); got != 5 {
+		t.Errorf("Int() with wrong type = %v, want 5", got)
+	}
+}
+
+// isolateUserDirs points the cache, home and Claude config directories at empty temp dirs,
+// so Generate neither writes to the real cache nor reads the machine's transcripts
+func isolateUserDirs(t *testing.T) {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("CLAUDE_CONFIG_DIR", t.TempDir())
+}
+
+// TestGenerateStatusLineWidgetOrder tests that configured order and visibility are honored
+func TestGenerateStatusLineWidgetOrder(t *testing.T) {
+	isolateUserDirs(t)
+	disabled := false
+	s := &StatusLine{
+		Theme: themes["minimal"],
+		Config: Config{Widgets: []WidgetConfig{
+			{Name: "model"},
+			{Name: "user", Enabled: &disabled},
+			{Name: "nonexistent"},
+			{Name: "path"},
+		}},
+	}
+
+	s.Generate(schema.StatusLineInput{
+		Model:     schema.ModelInfo{DisplayName: "Opus"},
+		Workspace: schema.WorkspaceInfo{CurrentDir: "/tmp"},
+	})
+
+	if len(s.Widgets) != 2 {
+		t.Fatalf("Generate() widgets = %d, want 2", len(s.Widgets))
+	}
+	if s.Widgets[0].Name != "model" || s.Widgets[1].Name != "path" {
+		t.Errorf("Generate() order = [%s %s], want [model path]", s.Widgets[0].Name, s.Widgets[1].Name)
+	}
+}
+
+// TestConfigWidgetLines tests multi-line layouts and their single-line fallbacks
+func TestConfigWidgetLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	content := `{"lines": [["user", "path"], [{"name": "cost", "enabled": false}, "model"]]}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	lines := config.WidgetLines()
+	if len(lines) != 2 || len(lines[0]) != 2 || lines[1][1].Name != "model" {
+		t.Errorf("WidgetLines() = %+v", lines)
+	}
+
+	if got := (Config{Widgets: []WidgetConfig{{Name: "git"}}}).WidgetLines(); len(got) != 1 || got[0][0].Name != "git" {
+		t.Errorf("WidgetLines() single line = %+v", got)
+	}
+	if got := (Config{}).WidgetLines(); len(got) != 1 || len(got[0]) != len(defaultWidgetOrder) {
+		t.Errorf("WidgetLines() default = %+v", got)
+	}
+}
+
+// TestGenerateStatusLineMultiLine tests that each configured line renders separately
+func TestGenerateStatusLineMultiLine(t *testing.T) {
+	isolateUserDirs(t)
+	s := &StatusLine{
+		Theme: themes["powerline"],
+		Config: Config{Lines: [][]WidgetConfig{
+			{{Name: "model"}, {Name: "path"}},
+			{{Name: "nonexistent"}},
+			{{Name: "model"}},
+		}},
+	}
+
+	output := s.Generate(schema.StatusLineInput{
+		Model:     schema.ModelInfo{DisplayName: "Opus"},
+		Workspace: schema.WorkspaceInfo{CurrentDir: "/tmp"},
+	})
+
+	lines := strings.Split(output, "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Generate() lines = %d, want 2 (empty lines dropped): %q", len(lines), output)
+	}
+	if !strings.Contains(lines[0], PowerlineRightArrow) {
+		t.Errorf("first line has no separator: %q", lines[0])
+	}
+	if strings.Contains(lines[1], PowerlineRightArrow) || !strings.Contains(lines[1], "opus") {
+		t.Errorf("second line = %q, want a lone model segment", lines[1])
+	}
+}
+
+// TestRenderWarnings tests that broken config and theme files are returned as
+// warnings while the line still renders with defaults
+func TestRenderWarnings(t *testing.T) {
+	configHome := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(configHome, "ccstatus"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configHome, "ccstatus", "config.json"), []byte(`{not json`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	env := schema.Env{
+		"HOME=" + t.TempDir(),
+		"CLAUDE_CONFIG_DIR=" + t.TempDir(),
+		"XDG_CONFIG_HOME=" + configHome,
+		"XDG_CACHE_HOME=" + t.TempDir(),
+		"CCSTATUS_THEME=" + filepath.Join(t.TempDir(), "missing.json"),
+	}
+
+	output, warnings, err := Render([]byte(`{"model":{"display_name":"Opus"}}`), env)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if !strings.Contains(output, "opus") {
+		t.Errorf("Render() = %q, want the line rendered with defaults", output)
+	}
+	if len(warnings) != 2 {
+		t.Fatalf("Render() warnings = %v, want config and theme", warnings)
+	}
+	for i, want := range []string{"loading config", "loading theme"} {
+		if !strings.HasPrefix(warnings[i].Error(), want) {
+			t.Errorf("warnings[%d] = %v, want %s", i, warnings[i], want)
+		}
+	}
+
+	if _, _, err := Render([]byte(`{not json`), env); err == nil {
+		t.Error("Render() with invalid input succeeded, want error")
+	}
+}
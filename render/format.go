@@ -0,0 +1,96 @@
+package render
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mrdavidaylward/ccstatus/schema"
+)
+
+// formatTokensAdvanced formats token counts with advanced display
+func formatTokensAdvanced(tokens int) string {
+	if tokens > 1000000 {
+		return fmt.Sprintf("%.1fM", float64(tokens)/1000000)
+	} else if tokens > 1000 {
+		return fmt.Sprintf("%.1fk", float64(tokens)/1000)
+	}
+	return strconv.Itoa(tokens)
+}
+
+// truncatePath truncates path to fit within maxLength
+func truncatePath(path string, maxLength int) string {
+	if len(path) <= maxLength {
+		return path
+	}
+
+	// Try to show end of path with ellipsis
+	if maxLength > 5 {
+		return "…" + path[len(path)-(maxLength-1):]
+	}
+	return path[:maxLength]
+}
+
+// formatCost formats cost values for display
+func formatCost(cost float64) string {
+	if cost < 0.01 {
+		return fmt.Sprintf("%.3f¢", cost*100)
+	} else if cost < 1.0 {
+		return fmt.Sprintf("%.2f¢", cost*100)
+	}
+	return fmt.Sprintf("$%.2f", cost)
+}
+
+// formatDuration formats elapsed time for display
+func formatDuration(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	if hours == 0 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %dm", hours, minutes)
+}
+
+// formatEfficiency formats efficiency percentage for display
+func formatEfficiency(efficiency float64) string {
+	return fmt.Sprintf("%.1f%%", efficiency)
+}
+
+func getModelDisplay(model schema.ModelInfo) string {
+	modelStr := model.DisplayName
+	if modelStr == "" {
+		modelStr = model.ID
+	}
+
+	modelLower := strings.ToLower(modelStr)
+
+	// Detect model type from name
+	if strings.Contains(modelLower, "sonnet") {
+		return "sonnet"
+	}
+	if strings.Contains(modelLower, "opus") {
+		return "opus"
+	}
+	if strings.Contains(modelLower, "haiku") {
+		return "haiku"
+	}
+
+	// Fallback to simplified name
+	return modelLower
+}
+
+func formatWorkspacePath(path string, env schema.Env) string {
+	homeDir, err := env.HomeDir()
+	if err != nil {
+		return path
+	}
+
+	if strings.HasPrefix(path, homeDir) {
+		return "~" + path[len(homeDir):]
+	}
+	return path
+}
@@ -0,0 +1,190 @@
+package render
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mrdavidaylward/ccstatus/schema"
+)
+
+// TestFormatTokensAdvanced tests token formatting
+func TestFormatTokensAdvanced(t *testing.T) {
+	tests := []struct {
+		name   string
+		tokens int
+		want   string
+	}{
+		{
+			name:   "small number",
+			tokens: 500,
+			want:   "500",
+		},
+		{
+			name:   "thousands",
+			tokens: 5000,
+			want:   "5.0k",
+		},
+		{
+			name:   "hundreds of thousands",
+			tokens: 172100,
+			want:   "172.1k",
+		},
+		{
+			name:   "millions",
+			tokens: 2500000,
+			want:   "2.5M",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := formatTokensAdvanced(tt.tokens)
+			if got != tt.want {
+				t.Errorf("formatTokensAdvanced() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFormatCost tests cost formatting
+func TestFormatCost(t *testing.T) {
+	tests := []struct {
+		name string
+		cost float64
+		want string
+	}{
+		{
+			name: "sub-penny",
+			cost: 0.005,
+			want: "0.500¢",
+		},
+		{
+			name: "cents",
+			cost: 0.15,
+			want: "15.00¢",
+		},
+		{
+			name: "dollars",
+			cost: 1.50,
+			want: "$1.50",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := formatCost(tt.cost)
+			if got != tt.want {
+				t.Errorf("formatCost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestTruncatePath tests path truncation
+func TestTruncatePath(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		maxLength int
+		want      string
+	}{
+		{
+			name:      "short path",
+			path:      "/home",
+			maxLength: 20,
+			want:      "/home",
+		},
+		{
+			name:      "long path",
+			path:      "/home/user/very/long/path/to/project",
+			maxLength: 20,
+			want:      "", // Just verify it's truncated (actual result depends on ellipsis encoding)
+		},
+		{
+			name:      "exact length",
+			path:      "12345",
+			maxLength: 5,
+			want:      "12345",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncatePath(tt.path, tt.maxLength)
+			// For long paths, just verify they're truncated (Unicode ellipsis complicates byte length)
+			if tt.want == "" {
+				if len(tt.path) <= tt.maxLength {
+					t.Errorf("truncatePath() should have truncated path of length %d with max %d", len(tt.path), tt.maxLength)
+				}
+			} else if got != tt.want {
+				t.Errorf("truncatePath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestGetModelDisplay tests model name simplification
+func TestGetModelDisplay(t *testing.T) {
+	tests := []struct {
+		name  string
+		model schema.ModelInfo
+		want  string
+	}{
+		{
+			name:  "sonnet display name",
+			model: schema.ModelInfo{DisplayName: "Sonnet 4"},
+			want:  "sonnet",
+		},
+		{
+			name:  "opus display name",
+			model: schema.ModelInfo{DisplayName: "Claude Opus 4"},
+			want:  "opus",
+		},
+		{
+			name:  "haiku display name",
+			model: schema.ModelInfo{DisplayName: "Haiku 3.5"},
+			want:  "haiku",
+		},
+		{
+			name:  "fallback to ID",
+			model: schema.ModelInfo{ID: "claude-3-sonnet"},
+			want:  "sonnet",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getModelDisplay(tt.model)
+			if got != tt.want {
+				t.Errorf("getModelDisplay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFormatDuration tests elapsed time formatting
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		name string
+		d    time.Duration
+		want string
+	}{
+		{name: "seconds", d: 42 * time.Second, want: "42s"},
+		{name: "minutes", d: 15*time.Minute + 30*time.Second, want: "15m"},
+		{name: "hours", d: 2*time.Hour + 5*time.Minute, want: "2h 5m"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatDuration(tt.d); got != tt.want {
+				t.Errorf("formatDuration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func BenchmarkFormatTokensAdvanced(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		formatTokensAdvanced(172100)
+	}
+}
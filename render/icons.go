@@ -0,0 +1,161 @@
+package render
+
+import (
+	"github.com/mrdavidaylward/ccstatus/internal/debug"
+)
+
+// IconSet maps icon names to the glyph or label shown in front of a widget's value
+type IconSet map[string]string
+
+// defaultIconSet is used when the config does not pick a set
+const defaultIconSet = "default"
+
+// iconSets are the built-in icon sets
+var iconSets = map[string]IconSet{
+	// The original mix of a powerline branch glyph and emoji
+	"default": {
+		"git":            GitBranch,
+		"timer":          BlockIcon,
+		"tokens":         TokenIcon,
+		"cost":           DollarIcon,
+		"messages":       MessageIcon,
+		"duration":       DurationIcon,
+		"api_duration":   LatencyIcon,
+		"efficiency":     EfficiencyIcon,
+		"compaction":     CompactionIcon,
+		"weekly":         WeeklyIcon,
+		"daily":          DailyIcon,
+		"git_staged":     "+",
+		"git_modified":   "!",
+		"git_untracked":  "?",
+		"git_conflicted": "=",
+		"git_stashed":    "≡",
+
+		"git_ahead":       "⇡",
+		"git_behind":      "⇣",
+		"git_no_upstream": "⊘",
+	},
+	// Nerd Font glyphs, all one column wide
+	"nerd": {
+		"git":            GitBranch,
+		"timer":          "\uF017", // clock
+		"tokens":         "\uF1C0", // database
+		"cost":           "\uF155", // dollar
+		"messages":       "\uF086", // comments
+		"duration":       "\uF254", // hourglass
+		"api_duration":   "\uF0E7", // bolt
+		"efficiency":     "\uF080", // bar chart
+		"compaction":     "\uF066", // compress
+		"weekly":         "\uF073", // calendar
+		"daily":          "\uF274", // calendar check
+		"git_staged":     "\uF067", // plus
+		"git_modified":   "\uF040", // pencil
+		"git_untracked":  "\uF128", // question
+		"git_conflicted": "\uF071", // warning
+		"git_stashed":    "\uF187", // archive
+
+		"git_ahead":       "\uF062", // arrow up
+		"git_behind":      "\uF063", // arrow down
+		"git_no_upstream": "\uF127", // broken link
+	},
+	"emoji": {
+		"git":            "🌿",
+		"timer":          "⏱️",
+		"tokens":         "🔤",
+		"cost":           "💰",
+		"messages":       "💬",
+		"duration":       "⌛",
+		"api_duration":   "⚡",
+		"efficiency":     "📊",
+		"compaction":     "🗜️",
+		"weekly":         "📅",
+		"daily":          "📆",
+		"git_staged":     "✅",
+		"git_modified":   "📝",
+		"git_untracked":  "❓",
+		"git_conflicted": "⚔️",
+		"git_stashed":    "📦",
+
+		"git_ahead":       "⬆️",
+		"git_behind":      "⬇️",
+		"git_no_upstream": "🚫",
+	},
+	// Narrow Unicode symbols that need no special font
+	"unicode": {
+		"git":            "⎇",
+		"timer":          "◷",
+		"tokens":         "≡",
+		"cost":           "$",
+		"messages":       "✉",
+		"duration":       "⧗",
+		"api_duration":   "↯",
+		"efficiency":     "◔",
+		"compaction":     "⇊",
+		"weekly":         "◫",
+		"daily":          "◻",
+		"git_staged":     "+",
+		"git_modified":   "!",
+		"git_untracked":  "?",
+		"git_conflicted": "=",
+		"git_stashed":    "≡",
+
+		"git_ahead":       "⇡",
+		"git_behind":      "⇣",
+		"git_no_upstream": "⊘",
+	},
+	"ascii": {
+		"git":            "git",
+		"timer":          "blk",
+		"tokens":         "tok",
+		"cost":           "$",
+		"messages":       "msg",
+		"duration":       "dur",
+		"api_duration":   "api",
+		"efficiency":     "eff",
+		"compaction":     "cmp",
+		"weekly":         "wk",
+		"daily":          "day",
+		"git_staged":     "+",
+		"git_modified":   "!",
+		"git_untracked":  "?",
+		"git_conflicted": "=",
+		"git_stashed":    "*",
+
+		"git_ahead":       "^",
+		"git_behind":      "v",
+		"git_no_upstream": "local",
+	},
+}
+
+// loadIconSet resolves the configured icon set and applies per-icon overrides
+func loadIconSet(config Config) IconSet {
+	base, ok := iconSets[config.IconSet]
+	if !ok {
+		if config.IconSet != "" {
+			debug.Log("Unknown icon set %q, using %s", config.IconSet, defaultIconSet)
+		}
+		base = iconSets[defaultIconSet]
+	}
+
+	icons := make(IconSet, len(base))
+	for name, icon := range base {
+		icons[name] = icon
+	}
+	for name, icon := range config.Icons {
+		if _, ok := base[name]; !ok {
+			debug.Log("Unknown icon %q in config, ignoring", name)
+			continue
+		}
+		icons[name] = icon
+	}
+	return icons
+}
+
+// Icon returns the named icon from the active set; widget formats place it with {icon}
+func (s *StatusLine) Icon(name string) string {
+	icons := s.Icons
+	if icons == nil {
+		icons = iconSets[defaultIconSet]
+	}
+	return icons[name]
+}
@@ -0,0 +1,61 @@
+package render
+
+import (
+	"testing"
+)
+
+// TestLoadIconSet tests set selection and per-icon overrides
+func TestLoadIconSet(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		icon   string
+		want   string
+	}{
+		{name: "default set", config: Config{}, icon: "tokens", want: TokenIcon},
+		{name: "ascii set", config: Config{IconSet: "ascii"}, icon: "messages", want: "msg"},
+		{name: "unknown set", config: Config{IconSet: "klingon"}, icon: "git", want: GitBranch},
+		{name: "override", config: Config{IconSet: "ascii", Icons: map[string]string{"tokens": "T"}}, icon: "tokens", want: "T"},
+		{name: "unknown override ignored", config: Config{Icons: map[string]string{"nope": "x"}}, icon: "nope", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := loadIconSet(tt.config)[tt.icon]; got != tt.want {
+				t.Errorf("loadIconSet()[%q] = %q, want %q", tt.icon, got, tt.want)
+			}
+		})
+	}
+
+	// Every set covers every icon of the default set
+	for name, set := range iconSets {
+		for icon := range iconSets[defaultIconSet] {
+			if set[icon] == "" {
+				t.Errorf("icon set %q has no %q icon", name, icon)
+			}
+		}
+	}
+
+	if iconSets[defaultIconSet]["tokens"] != TokenIcon {
+		t.Error("loadIconSet() modified the built-in set")
+	}
+}
+
+// TestWidgetIcons tests icons in widget formats, including icons overridden to nothing
+func TestWidgetIcons(t *testing.T) {
+	s := &StatusLine{Icons: loadIconSet(Config{IconSet: "ascii", Icons: map[string]string{"cost": ""}})}
+
+	fields := TemplateFields{"tokens": 12345, "cost": 1.2}
+	s.AddFormattedWidget("tokens", nil, "{icon} {tokens|tokens}", "", fields.With(TemplateFields{"icon": s.Icon("tokens")}), "", "")
+	s.AddFormattedWidget("cost", nil, "{icon} {cost|currency}", "", fields.With(TemplateFields{"icon": s.Icon("cost")}), "", "")
+
+	if got := s.Widgets[0].Content; got != "tok 12.3k" {
+		t.Errorf("tokens widget = %q, want %q", got, "tok 12.3k")
+	}
+	if got := s.Widgets[1].Content; got != "$1.20" {
+		t.Errorf("cost widget with empty icon = %q, want %q", got, "$1.20")
+	}
+	if got := (&StatusLine{}).Icon("messages"); got != MessageIcon {
+		t.Errorf("Icon() without icon set = %q, want %q", got, MessageIcon)
+	}
+}
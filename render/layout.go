@@ -0,0 +1,226 @@
+package render
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Responsive layout: when a target width is known, each line is shortened
+// until it fits by first switching low-priority widgets to their compact form
+// and then dropping them, lowest priority first.
+
+// defaultPriority applies to widgets without an entry in defaultWidgetPriorities
+const defaultPriority = 50
+
+// defaultWidgetPriorities ranks widgets by importance; higher survives longer on narrow terminals
+var defaultWidgetPriorities = map[string]int{
+	"model":        90,
+	"percent":      85,
+	"path":         80,
+	"git":          70,
+	"cost":         60,
+	"weekly":       55,
+	"compaction":   50,
+	"reset":        45,
+	"timer":        40,
+	"tokens":       35,
+	"messages":     30,
+	"duration":     25,
+	"user":         20,
+	"lines":        20,
+	"efficiency":   15,
+	"api_duration": 10,
+}
+
+// widgetPriority returns the configured priority for a widget, or its default
+func widgetPriority(wc WidgetConfig) int {
+	def, ok := defaultWidgetPriorities[wc.Name]
+	if !ok {
+		def = defaultPriority
+	}
+	return wc.Options.Int("priority", def)
+}
+
+// getTerminalWidth returns the width to fit lines into: the configured width, else COLUMNS, else 0 (unlimited)
+func getTerminalWidth(config Config, getenv func(string) string) int {
+	if config.Width > 0 {
+		return config.Width
+	}
+	if columns, err := strconv.Atoi(strings.TrimSpace(getenv("COLUMNS"))); err == nil && columns > 0 {
+		return columns
+	}
+	return 0
+}
+
+// fitLine shortens a line of widgets until it renders within width columns
+func (s *StatusLine) fitLine(widgets []Widget, width int) []Widget {
+	fits := func(ws []Widget) bool {
+		return visibleWidth(s.renderLine(ws)) <= width
+	}
+	if width <= 0 || fits(widgets) {
+		return widgets
+	}
+
+	widgets = append([]Widget(nil), widgets...)
+
+	// Lowest priority first; among equals, the rightmost goes first
+	order := make([]int, len(widgets))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		pa, pb := widgets[order[a]].Priority, widgets[order[b]].Priority
+		if pa != pb {
+			return pa < pb
+		}
+		return order[a] > order[b]
+	})
+
+	for _, i := range order {
+		if widgets[i].Compact == "" {
+			continue
+		}
+		widgets[i].Content = widgets[i].Compact
+		widgets[i].Compact = ""
+		if fits(widgets) {
+			return widgets
+		}
+	}
+
+	dropped := make(map[int]bool)
+	remaining := func() []Widget {
+		var kept []Widget
+		for i, widget := range widgets {
+			if !dropped[i] {
+				kept = append(kept, widget)
+			}
+		}
+		return kept
+	}
+	for _, i := range order[:len(order)-1] { // Always keep the most important widget
+		dropped[i] = true
+		if kept := remaining(); fits(kept) {
+			return kept
+		}
+	}
+	return remaining()
+}
+
+// visibleWidth returns the number of terminal columns s occupies, ignoring ANSI escape sequences
+func visibleWidth(s string) int {
+	width := 0
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r == '\033' {
+			i = skipEscape(runes, i)
+			continue
+		}
+		switch {
+		case r == '\ufe0f':
+			// Emoji presentation selector widens a text-style symbol to two columns
+			if i > 0 && !isWideRune(runes[i-1]) {
+				width++
+			}
+		case r == '\u200d' || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r):
+			// Zero-width joiners, other variation selectors and combining marks
+		case unicode.IsControl(r):
+		case isWideRune(r):
+			width += 2
+		default:
+			width++
+		}
+	}
+	return width
+}
+
+// skipEscape returns the index of the last rune of the escape sequence starting at i
+func skipEscape(runes []rune, i int) int {
+	if i+1 >= len(runes) || runes[i+1] != '[' {
+		return i
+	}
+	// CSI sequences end with a final byte in the range 0x40-0x7E
+	for j := i + 2; j < len(runes); j++ {
+		if runes[j] >= 0x40 && runes[j] <= 0x7e {
+			return j
+		}
+	}
+	return len(runes) - 1
+}
+
+// wideRanges are East Asian wide characters and emoji that render two columns wide by default
+var wideRanges = []struct{ lo, hi rune }{
+	{0x1100, 0x115f},   // Hangul Jamo
+	{0x231a, 0x231b},   // Watch, hourglass
+	{0x23e9, 0x23ec},   // Fast-forward buttons
+	{0x23f0, 0x23f0},   // Alarm clock
+	{0x23f3, 0x23f3},   // Hourglass with flowing sand
+	{0x25fd, 0x25fe},   // Small squares
+	{0x2614, 0x2615},   // Umbrella, hot beverage
+	{0x2648, 0x2653},   // Zodiac
+	{0x267f, 0x267f},   // Wheelchair
+	{0x2693, 0x2693},   // Anchor
+	{0x26a1, 0x26a1},   // High voltage
+	{0x26aa, 0x26ab},   // Circles
+	{0x26bd, 0x26be},   // Balls
+	{0x26c4, 0x26c5},   // Snowman, sun
+	{0x26ce, 0x26ce},   // Ophiuchus
+	{0x26d4, 0x26d4},   // No entry
+	{0x26ea, 0x26ea},   // Church
+	{0x26f2, 0x26f3},   // Fountain, golf
+	{0x26f5, 0x26f5},   // Sailboat
+	{0x26fa, 0x26fa},   // Tent
+	{0x26fd, 0x26fd},   // Fuel pump
+	{0x2705, 0x2705},   // Check mark button
+	{0x270a, 0x270b},   // Fists
+	{0x2728, 0x2728},   // Sparkles
+	{0x274c, 0x274c},   // Cross mark
+	{0x274e, 0x274e},   // Cross mark button
+	{0x2753, 0x2755},   // Question marks
+	{0x2757, 0x2757},   // Exclamation mark
+	{0x2795, 0x2797},   // Plus, minus, divide
+	{0x27b0, 0x27b0},   // Curly loop
+	{0x27bf, 0x27bf},   // Double curly loop
+	{0x2b1b, 0x2b1c},   // Large squares
+	{0x2b50, 0x2b50},   // Star
+	{0x2b55, 0x2b55},   // Circle
+	{0x2e80, 0x303e},   // CJK radicals and punctuation
+	{0x3041, 0x33ff},   // Kana and CJK symbols
+	{0x3400, 0x4dbf},   // CJK extension A
+	{0x4e00, 0x9fff},   // CJK unified ideographs
+	{0xa000, 0xa4cf},   // Yi
+	{0xac00, 0xd7a3},   // Hangul syllables
+	{0xf900, 0xfaff},   // CJK compatibility ideographs
+	{0xfe30, 0xfe4f},   // CJK compatibility forms
+	{0xff00, 0xff60},   // Fullwidth forms
+	{0xffe0, 0xffe6},   // Fullwidth signs
+	{0x1f004, 0x1f004}, // Mahjong tile
+	{0x1f0cf, 0x1f0cf}, // Joker
+	{0x1f18e, 0x1f18e}, // AB button
+	{0x1f191, 0x1f19a}, // Squared words
+	{0x1f200, 0x1f2ff}, // Enclosed ideographic supplement
+	{0x1f300, 0x1f64f}, // Pictographs and emoticons
+	{0x1f680, 0x1f6ff}, // Transport and map symbols
+	{0x1f7e0, 0x1f7eb}, // Colored circles and squares
+	{0x1f900, 0x1f9ff}, // Supplemental symbols and pictographs
+	{0x1fa70, 0x1faff}, // Symbols and pictographs extended-A
+	{0x20000, 0x3fffd}, // CJK extensions B and beyond
+}
+
+// isWideRune reports whether r occupies two terminal columns
+func isWideRune(r rune) bool {
+	if r < 0x1100 {
+		return false
+	}
+	for _, wide := range wideRanges {
+		if r < wide.lo {
+			return false
+		}
+		if r <= wide.hi {
+			return true
+		}
+	}
+	return false
+}
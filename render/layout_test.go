@@ -0,0 +1,123 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestVisibleWidth tests column counting with ANSI codes and wide characters
+func TestVisibleWidth(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{name: "plain", text: "main±3", want: 6},
+		{name: "ansi codes", text: "\033[48;2;60;56;54m\033[97m opus \033[0m", want: 6},
+		{name: "wide emoji", text: "💬 23/45", want: 8},
+		{name: "emoji presentation selector", text: "🗜️ 68%", want: 6},
+		{name: "text symbol with selector", text: "⏱️", want: 2},
+		{name: "narrow symbol", text: "⏱ 2h", want: 4},
+		{name: "powerline glyph", text: PowerlineRightArrow, want: 1},
+		{name: "cjk", text: "日本", want: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := visibleWidth(tt.text); got != tt.want {
+				t.Errorf("visibleWidth(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFitLine tests compacting and dropping widgets by priority
+func TestFitLine(t *testing.T) {
+	s := &StatusLine{Theme: themes["minimal"]}
+	widgets := []Widget{
+		{Name: "model", Content: "opus", Priority: 90},
+		{Name: "messages", Content: "💬 23/45", Compact: "23", Priority: 30},
+		{Name: "user", Content: "dev@host", Compact: "dev", Priority: 20},
+	}
+	// Full line: "opus | 💬 23/45 | dev@host" is 26 columns
+
+	tests := []struct {
+		name  string
+		width int
+		want  []string
+	}{
+		{name: "unlimited", width: 0, want: []string{"opus", "💬 23/45", "dev@host"}},
+		{name: "fits", width: 26, want: []string{"opus", "💬 23/45", "dev@host"}},
+		{name: "compact lowest first", width: 21, want: []string{"opus", "💬 23/45", "dev"}},
+		{name: "compact all", width: 16, want: []string{"opus", "23", "dev"}},
+		{name: "drop lowest", width: 10, want: []string{"opus", "23"}},
+		{name: "keep most important", width: 2, want: []string{"opus"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := s.fitLine(widgets, tt.width)
+			if len(got) != len(tt.want) {
+				t.Fatalf("fitLine() = %+v, want %v", got, tt.want)
+			}
+			for i, content := range tt.want {
+				if got[i].Content != content {
+					t.Errorf("fitLine()[%d] = %q, want %q", i, got[i].Content, content)
+				}
+			}
+		})
+	}
+
+	if widgets[2].Content != "dev@host" {
+		t.Error("fitLine() modified its input")
+	}
+}
+
+// TestGetTerminalWidth tests configured width and COLUMNS fallbacks
+func TestGetTerminalWidth(t *testing.T) {
+	env := func(columns string) func(string) string {
+		return func(key string) string {
+			if key == "COLUMNS" {
+				return columns
+			}
+			return ""
+		}
+	}
+
+	if got := getTerminalWidth(Config{Width: 80}, env("120")); got != 80 {
+		t.Errorf("getTerminalWidth() configured = %v, want 80", got)
+	}
+	if got := getTerminalWidth(Config{}, env("120")); got != 120 {
+		t.Errorf("getTerminalWidth() COLUMNS = %v, want 120", got)
+	}
+	if got := getTerminalWidth(Config{}, env("wide")); got != 0 {
+		t.Errorf("getTerminalWidth() invalid COLUMNS = %v, want 0", got)
+	}
+}
+
+// TestRenderLineRightGroup tests padding and left-pointing separators for right-aligned widgets
+func TestRenderLineRightGroup(t *testing.T) {
+	s := &StatusLine{Theme: themes["powerline"], Width: 40}
+	widgets := []Widget{
+		{Name: "path", Content: "~/src", Color: ColorWhite, BgColor: BgBlue},
+		{Name: "percent", Content: "80%", Color: ColorBlack, BgColor: BgGreen, Right: true},
+		{Name: "timer", Content: "2h", Color: ColorWhite, BgColor: BgMagenta, Right: true},
+	}
+
+	line := s.renderLine(widgets)
+	if got := visibleWidth(line); got != 40 {
+		t.Errorf("renderLine() width = %d, want 40: %q", got, line)
+	}
+	if strings.Count(line, PowerlineLeftArrow) != 2 || strings.Contains(line, PowerlineRightArrow) {
+		t.Errorf("renderLine() separators = %q, want two left arrows", line)
+	}
+	if !strings.HasSuffix(line, " 2h "+ColorReset) {
+		t.Errorf("renderLine() does not end with the last right widget: %q", line)
+	}
+
+	// Without room the groups keep a single space between them
+	s.Width = 0
+	if got := visibleWidth(s.renderLine(widgets)); got != 19 {
+		t.Errorf("renderLine() unpadded width = %d, want 19", got)
+	}
+}
@@ -0,0 +1,78 @@
+package render
+
+// WidgetProvider supplies a widget that config files can reference by name.
+// Registering a provider is all a new widget needs: the generation loop, theme
+// files and layout pick it up by name.
+type WidgetProvider interface {
+	// Collect gathers the widget's template fields; ok is false when there is nothing to show
+	Collect(s *StatusLine, ctx *RenderContext, opts WidgetOptions) (fields TemplateFields, ok bool)
+	// Render adds the widget's segments from the collected fields, colored by style
+	Render(s *StatusLine, fields TemplateFields, opts WidgetOptions, style WidgetStyle)
+	// DefaultStyle colors the widget when the theme has no style for it
+	DefaultStyle() WidgetStyle
+}
+
+// widgetProviders maps config widget names to their providers
+var widgetProviders = map[string]WidgetProvider{}
+
+// RegisterWidget makes a widget available to config files and theme files under
+// name, replacing any built-in widget of the same name. Call it before Render.
+func RegisterWidget(name string, provider WidgetProvider) {
+	widgetProviders[name] = provider
+}
+
+// buildWidget collects and renders the named widget; ok is false for unknown names
+func (s *StatusLine) buildWidget(name string, ctx *RenderContext, opts WidgetOptions) bool {
+	provider, ok := widgetProviders[name]
+	if !ok {
+		return false
+	}
+	fields, ok := provider.Collect(s, ctx, opts)
+	if !ok {
+		return true
+	}
+	provider.Render(s, fields, opts, s.Theme.Style(name, provider.DefaultStyle()))
+	return true
+}
+
+// templateWidget is a provider for widgets that render a format template over the
+// shared fields, needing at most a visibility check, an icon and threshold colors
+type templateWidget struct {
+	name    string
+	format  string // Default format; the "format" option overrides it
+	compact string // Default compact format; the "compact_format" option overrides it
+	icon    bool   // Adds the widget's icon as {icon}
+	level   string // Field whose percentage picks threshold colors, if any
+	style   WidgetStyle
+
+	// show reports whether there is anything to display; nil always shows the widget
+	show func(s *StatusLine, ctx *RenderContext) bool
+}
+
+// Collect returns the shared fields, plus {icon} when the widget has one
+func (w templateWidget) Collect(s *StatusLine, ctx *RenderContext, opts WidgetOptions) (TemplateFields, bool) {
+	if w.show != nil && !w.show(s, ctx) {
+		return nil, false
+	}
+	fields := s.Fields(ctx)
+	if w.icon {
+		fields = fields.With(TemplateFields{"icon": s.Icon(w.name)})
+	}
+	return fields, true
+}
+
+// Render adds the formatted widget, colored by the level field when the widget has one
+func (w templateWidget) Render(s *StatusLine, fields TemplateFields, opts WidgetOptions, style WidgetStyle) {
+	color, bgColor := style.Fg, style.Bg
+	if w.level != "" {
+		level, _ := fields.Get(w.level)
+		percent, _ := level.(int)
+		color, bgColor = style.Colors(percent)
+	}
+	s.AddFormattedWidget(w.name, opts, w.format, w.compact, fields, color, bgColor)
+}
+
+// DefaultStyle returns the widget's built-in style
+func (w templateWidget) DefaultStyle() WidgetStyle {
+	return w.style
+}
@@ -0,0 +1,67 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mrdavidaylward/ccstatus/schema"
+)
+
+// sessionWidget is a provider defined outside the built-in set
+type sessionWidget struct{}
+
+func (sessionWidget) Collect(s *StatusLine, ctx *RenderContext, opts WidgetOptions) (TemplateFields, bool) {
+	if ctx.Input.SessionID == "" {
+		return nil, false
+	}
+	return TemplateFields{"id": ctx.Input.SessionID}, true
+}
+
+func (sessionWidget) Render(s *StatusLine, fields TemplateFields, opts WidgetOptions, style WidgetStyle) {
+	s.AddFormattedWidget("session", opts, "#{id}", "", fields, style.Fg, style.Bg)
+}
+
+func (sessionWidget) DefaultStyle() WidgetStyle {
+	return WidgetStyle{Fg: ColorCyan, Fallback: "model"}
+}
+
+// TestRegisterWidget tests that a registered provider renders and can be themed by name
+func TestRegisterWidget(t *testing.T) {
+	isolateUserDirs(t)
+	RegisterWidget("session", sessionWidget{})
+	defer delete(widgetProviders, "session")
+
+	generate := func(theme Theme, sessionID string) []Widget {
+		s := &StatusLine{Theme: theme, Config: Config{Widgets: []WidgetConfig{{Name: "session"}}}}
+		s.Generate(schema.StatusLineInput{SessionID: sessionID})
+		return s.Widgets
+	}
+
+	widgets := generate(themes["minimal"], "abc")
+	if len(widgets) != 1 || widgets[0].Content != "#abc" {
+		t.Fatalf("Generate() widgets = %+v, want one #abc widget", widgets)
+	}
+	if widgets[0].Color != ColorBrightMagenta {
+		t.Errorf("widget color = %q, want the theme's model color", widgets[0].Color)
+	}
+
+	if widgets := generate(Theme{}, "abc"); len(widgets) != 1 || widgets[0].Color != ColorCyan {
+		t.Errorf("widget without theme style = %+v, want default color", widgets)
+	}
+	if widgets := generate(themes["minimal"], ""); len(widgets) != 0 {
+		t.Errorf("Generate() without data = %+v, want no widgets", widgets)
+	}
+
+	path := filepath.Join(t.TempDir(), "session.json")
+	if err := os.WriteFile(path, []byte(`{"extends": "minimal", "colors": {"session": {"fg": "red"}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	theme, err := LoadTheme(path, nil)
+	if err != nil {
+		t.Fatalf("LoadTheme() error = %v", err)
+	}
+	if widgets := generate(theme, "abc"); len(widgets) != 1 || widgets[0].Color != ColorRed {
+		t.Errorf("themed widget = %+v, want red", widgets)
+	}
+}
@@ -0,0 +1,57 @@
+package render
+
+// SeparatorStyle is a matched set of segment separators and line-end caps
+type SeparatorStyle struct {
+	Right     string // Between left-group segments with backgrounds
+	RightThin string // Between left-group segments without backgrounds
+	Left      string // Leading into right-group segments with backgrounds
+	LeftThin  string // Leading into right-group segments without backgrounds
+	Head      string // Opens a group when the theme enables caps
+	Tail      string // Closes a group when the theme enables caps
+}
+
+// defaultSeparatorStyle is used by themes that do not pick a style
+const defaultSeparatorStyle = "powerline"
+
+// separatorStyles are the built-in separator sets; all but ascii and none need a Nerd Font
+var separatorStyles = map[string]SeparatorStyle{
+	"powerline": {
+		Right: PowerlineRightArrow, RightThin: PowerlineRightThinArrow,
+		Left: PowerlineLeftArrow, LeftThin: PowerlineLeftThinArrow,
+		Head: PowerlineLeftArrow, Tail: PowerlineRightArrow,
+	},
+	"rounded": {
+		Right: "\uE0B4", RightThin: "\uE0B5",
+		Left: "\uE0B6", LeftThin: "\uE0B7",
+		Head: "\uE0B6", Tail: "\uE0B4",
+	},
+	"slanted": {
+		Right: "\uE0BC", RightThin: "\uE0BD",
+		Left: "\uE0BA", LeftThin: "\uE0BB",
+		Head: "\uE0BA", Tail: "\uE0BC",
+	},
+	"flame": {
+		Right: "\uE0C0", RightThin: "\uE0C1",
+		Left: "\uE0C2", LeftThin: "\uE0C3",
+		Head: "\uE0C2", Tail: "\uE0C0",
+	},
+	"pixelated": {
+		Right: "\uE0C4", RightThin: PowerlineRightThinArrow,
+		Left: "\uE0C5", LeftThin: PowerlineLeftThinArrow,
+		Head: "\uE0C5", Tail: "\uE0C4",
+	},
+	"ascii": {
+		Right: ">", RightThin: ">",
+		Left: "<", LeftThin: "<",
+		Head: "[", Tail: "]",
+	},
+	"none": {},
+}
+
+// separatorStyle returns the theme's separator set
+func (s *StatusLine) separatorStyle() SeparatorStyle {
+	if style, ok := separatorStyles[s.Theme.SeparatorStyle]; ok {
+		return style
+	}
+	return separatorStyles[defaultSeparatorStyle]
+}
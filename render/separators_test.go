@@ -0,0 +1,47 @@
+package render
+
+import (
+	"regexp"
+	"testing"
+)
+
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// TestSeparatorStyles tests separators and caps for each style
+func TestSeparatorStyles(t *testing.T) {
+	widgets := []Widget{
+		{Name: "model", Content: "opus", Color: ColorWhite, BgColor: BgBlue},
+		{Name: "path", Content: "~/src", Color: ColorWhite, BgColor: BgMagenta},
+		{Name: "timer", Content: "2h", Color: ColorWhite, BgColor: BgGreen, Right: true},
+	}
+
+	tests := []struct {
+		name  string
+		theme string
+		style string
+		caps  bool
+		want  string
+	}{
+		{name: "default powerline", theme: "powerline", want: " opus \uE0B0 ~/src  \uE0B2 2h "},
+		{name: "rounded with caps", theme: "powerline", style: "rounded", caps: true, want: "\uE0B6 opus \uE0B4 ~/src \uE0B4 \uE0B6 2h \uE0B4"},
+		{name: "ascii", theme: "powerline", style: "ascii", want: " opus > ~/src  < 2h "},
+		{name: "ascii with caps", theme: "powerline", style: "ascii", caps: true, want: "[ opus > ~/src ] < 2h ]"},
+		{name: "none", theme: "powerline", style: "none", want: " opus  ~/src   2h "},
+		{name: "minimal keeps pipe", theme: "minimal", want: "opus | ~/src 2h"},
+		{name: "minimal with style", theme: "minimal", style: "ascii", caps: true, want: "opus > ~/src 2h"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			theme := themes[tt.theme]
+			theme.SeparatorStyle = tt.style
+			theme.Caps = tt.caps
+			s := &StatusLine{Theme: theme}
+
+			got := ansiPattern.ReplaceAllString(s.renderLine(widgets), "")
+			if got != tt.want {
+				t.Errorf("renderLine() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,393 @@
+// Package render builds and draws the status line: themes, the config file,
+// widget builders, templates, layout and separators. Render is the whole
+// pipeline from input JSON to the printed line; other binaries can add widgets
+// with RegisterWidget before calling it.
+package render
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mrdavidaylward/ccstatus/internal/debug"
+	"github.com/mrdavidaylward/ccstatus/schema"
+	"github.com/mrdavidaylward/ccstatus/source"
+	"github.com/mrdavidaylward/ccstatus/usage"
+)
+
+// Version is reported to templates as {version}; binaries set it from their build flags
+var Version = "dev"
+
+// Widget represents a status line widget
+type Widget struct {
+	Name     string
+	Content  string
+	Color    string
+	BgColor  string
+	Line     int    // Output line the widget is rendered on
+	Priority int    // Higher priority widgets are kept longer when space is short
+	Compact  string // Shorter content used when space is short, if any
+	Right    bool   // Rendered in the right-aligned group
+}
+
+// StatusLine holds the complete status line configuration
+type StatusLine struct {
+	Theme     Theme
+	Config    Config
+	Pricing   usage.PricingTable
+	Widgets   []Widget
+	StartTime time.Time
+	Width     int        // Columns to fit each line into, 0 for unlimited
+	Colors    ColorDepth // Terminal color depth theme colors are downsampled to
+	Icons     IconSet
+	Env       schema.Env // Environment of the process the line is rendered for; nil for ours
+
+	currentLine int // Line new widgets are added to while building
+}
+
+// Render renders the status line for raw Claude Code JSON input.
+// env is the caller's environment, which differs from ours when running as a daemon.
+// A broken config, theme or pricing file falls back to defaults and is reported in
+// warnings rather than failing the render; what to print is up to the caller.
+func Render(input []byte, env schema.Env) (output string, warnings []error, err error) {
+	// Parse JSON input
+	statusInput, err := schema.Parse(input)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Load config file (missing file means defaults)
+	config, err := LoadConfig(getConfigPath(env))
+	if err != nil {
+		warnings = append(warnings, fmt.Errorf("loading config: %w", err))
+	}
+
+	// Initialize status line with theme (env overrides config, default: powerline)
+	themeName := env.Getenv("CCSTATUS_THEME")
+	if themeName == "" {
+		themeName = config.Theme
+	}
+	if themeName == "" {
+		themeName = "powerline"
+	}
+
+	theme, err := LoadTheme(themeName, env)
+	if err != nil {
+		warnings = append(warnings, fmt.Errorf("loading theme: %w", err))
+		theme = themes["powerline"]
+	}
+
+	pricing, err := usage.LoadPricingTable(getPricingPath(config, env))
+	if err != nil {
+		warnings = append(warnings, fmt.Errorf("loading pricing: %w", err))
+	}
+
+	// Create status line
+	statusLine := &StatusLine{
+		Theme:     theme,
+		Config:    config,
+		Pricing:   pricing,
+		StartTime: time.Now(), // This would be session start in real implementation
+		Width:     getTerminalWidth(config, env.Getenv),
+		Colors:    detectColorDepth(config, env.Getenv),
+		Icons:     loadIconSet(config),
+		Env:       env,
+	}
+
+	// Generate enhanced status line
+	return statusLine.Generate(statusInput), warnings, nil
+}
+
+// Generate creates a powerline-style status line
+func (s *StatusLine) Generate(input schema.StatusLineInput) string {
+	// Collect data (using cached version for performance)
+	ccusageData := source.CCUsage(input.SessionID, s.Env)
+	calculatedUsage := source.ScriptUsage(s.Env)
+	transcriptUsage := usage.LoadTranscriptUsage(input, s.Env)
+
+	// Extract token usage from JSON input
+	inputTokens := input.InputTokenCount()
+	outputTokens := input.OutputTokenCount()
+	totalTokens := input.TotalTokenCount()
+	contextTokens := input.ContextTokenCount()
+	contextChars := input.ContextCharacterCount()
+	if contextTokens == 0 {
+		contextTokens = transcriptUsage.ContextTokens
+	}
+
+	// Check if we're in a new 5hr window - if so, reset session counters
+	sessionStartTime := source.SessionStartTime(s.Env)
+	isNewSession := false
+	if !sessionStartTime.IsZero() {
+		elapsed := time.Since(sessionStartTime)
+		if elapsed >= 5*time.Hour {
+			isNewSession = true
+		}
+	}
+
+	// Calculate actual token usage (prefer calculated data, then ccusage, then JSON)
+	var dailyTokensUsed int
+	var sessionInputTokens, sessionOutputTokens int
+
+	if isNewSession {
+		// New 5hr session - use current request tokens as starting point
+		dailyTokensUsed = inputTokens + outputTokens
+		sessionInputTokens = inputTokens
+		sessionOutputTokens = outputTokens
+	} else if calculatedUsage.DailyTokens > 0 {
+		dailyTokensUsed = calculatedUsage.DailyTokens
+		sessionInputTokens = calculatedUsage.InputTokens
+		sessionOutputTokens = calculatedUsage.OutputTokens
+	} else if ccusageData.DailyTokens > 0 {
+		dailyTokensUsed = ccusageData.DailyTokens
+		sessionInputTokens = ccusageData.InputTokens
+		sessionOutputTokens = ccusageData.OutputTokens
+	} else if totalTokens > 0 {
+		dailyTokensUsed = totalTokens
+		sessionInputTokens = inputTokens
+		sessionOutputTokens = outputTokens
+	} else {
+		dailyTokensUsed = inputTokens + outputTokens
+		sessionInputTokens = inputTokens
+		sessionOutputTokens = outputTokens
+	}
+
+	// The session transcript is authoritative for this session's own token counts
+	if transcriptUsage.Usage.TotalTokens() > 0 {
+		sessionInputTokens = transcriptUsage.Usage.InputTokens
+		sessionOutputTokens = transcriptUsage.Usage.OutputTokens
+		if calculatedUsage.DailyTokens == 0 && ccusageData.DailyTokens == 0 {
+			dailyTokensUsed = transcriptUsage.Usage.TotalTokens()
+		}
+	}
+
+	ctx := &RenderContext{
+		Input:               input,
+		Model:               usage.ResolveActiveModel(input, transcriptUsage.Model, contextTokens, s.pricing()),
+		CCUsage:             ccusageData,
+		Calculated:          calculatedUsage,
+		Transcript:          transcriptUsage,
+		DailyTokens:         dailyTokensUsed,
+		SessionInputTokens:  sessionInputTokens,
+		SessionOutputTokens: sessionOutputTokens,
+		ContextTokens:       contextTokens,
+		ContextChars:        contextChars,
+	}
+
+	// Build widgets in the configured order
+	s.Widgets = []Widget{}
+
+	for line, widgetConfigs := range s.Config.WidgetLines() {
+		s.currentLine = line
+		for _, wc := range widgetConfigs {
+			if !wc.IsEnabled() {
+				continue
+			}
+			start := len(s.Widgets)
+			if !s.buildWidget(wc.Name, ctx, wc.Options) {
+				debug.Log("Unknown widget %q in config, skipping", wc.Name)
+				continue
+			}
+			priority := widgetPriority(wc)
+			_, pinned := wc.Options["priority"]
+			right := wc.Options.String("align", "left") == "right"
+			for i := start; i < len(s.Widgets); i++ {
+				// Widgets may pick their own priority unless the config sets one
+				if pinned || s.Widgets[i].Priority == 0 {
+					s.Widgets[i].Priority = priority
+				}
+				s.Widgets[i].Right = right
+			}
+		}
+	}
+
+	// Render widgets with powerline separators
+	return s.renderPowerline()
+}
+
+// pricing returns the loaded pricing table, or the built-in one
+func (s *StatusLine) pricing() usage.PricingTable {
+	if s.Pricing != nil {
+		return s.Pricing
+	}
+	return usage.DefaultPricing
+}
+
+// AddWidget adds a widget to the status line
+func (s *StatusLine) AddWidget(name, content, color, bgColor string) {
+	s.AddWidgetCompact(name, content, "", color, bgColor)
+}
+
+// AddWidgetCompact adds a widget with a shorter form for narrow terminals
+func (s *StatusLine) AddWidgetCompact(name, content, compact, color, bgColor string) {
+	s.Widgets = append(s.Widgets, Widget{
+		Name:    name,
+		Content: content,
+		Color:   color,
+		BgColor: bgColor,
+		Line:    s.currentLine,
+		Compact: compact,
+	})
+}
+
+// renderPowerline renders each line of widgets with powerline-style separators
+func (s *StatusLine) renderPowerline() string {
+	var lines []string
+	for _, widgets := range s.widgetLines() {
+		widgets = s.fitLine(widgets, s.Width)
+		if line := s.renderLine(widgets); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return adaptColors(strings.Join(lines, "\n"), s.Colors)
+}
+
+// widgetLines groups widgets by output line, preserving their order
+func (s *StatusLine) widgetLines() [][]Widget {
+	var lines [][]Widget
+	for _, widget := range s.Widgets {
+		for len(lines) <= widget.Line {
+			lines = append(lines, nil)
+		}
+		lines[widget.Line] = append(lines[widget.Line], widget)
+	}
+	return lines
+}
+
+// renderLine renders one line of widgets with its own segments and separators,
+// padding the right-aligned group out to the target width
+func (s *StatusLine) renderLine(widgets []Widget) string {
+	var left, right []Widget
+	for _, widget := range widgets {
+		if widget.Right {
+			right = append(right, widget)
+		} else {
+			left = append(left, widget)
+		}
+	}
+
+	leftPart := s.renderLeftGroup(left)
+	rightPart := s.renderRightGroup(right)
+	if rightPart == "" {
+		return leftPart
+	}
+
+	padding := s.Width - visibleWidth(leftPart) - visibleWidth(rightPart)
+	if padding < 1 {
+		padding = 1
+	}
+	return leftPart + strings.Repeat(" ", padding) + rightPart
+}
+
+// renderSegment renders a widget's content with its colors
+func (s *StatusLine) renderSegment(widget Widget) string {
+	if s.Theme.UsePowerline && widget.BgColor != "" {
+		// Powerline segment with background
+		return fmt.Sprintf("%s%s %s %s", widget.BgColor, widget.Color, widget.Content, ColorReset)
+	}
+	// Simple colored text
+	return fmt.Sprintf("%s%s%s", widget.Color, widget.Content, ColorReset)
+}
+
+// renderLeftGroup renders widgets left to right with right-pointing separators
+func (s *StatusLine) renderLeftGroup(widgets []Widget) string {
+	if len(widgets) == 0 {
+		return ""
+	}
+
+	var parts []string
+	parts = append(parts, s.getCap(widgets[0], s.separatorStyle().Head))
+
+	for i, widget := range widgets {
+		parts = append(parts, s.renderSegment(widget))
+
+		// Add separator (except for last widget)
+		if i < len(widgets)-1 {
+			nextWidget := widgets[i+1]
+			separator := s.getSeparator(widget, nextWidget)
+			parts = append(parts, separator)
+		}
+	}
+
+	parts = append(parts, s.getCap(widgets[len(widgets)-1], s.separatorStyle().Tail))
+	return strings.Join(parts, "")
+}
+
+// renderRightGroup renders widgets with left-pointing separators, each leading into its segment
+func (s *StatusLine) renderRightGroup(widgets []Widget) string {
+	var parts []string
+	for i, widget := range widgets {
+		var previous *Widget
+		if i > 0 {
+			previous = &widgets[i-1]
+		}
+		parts = append(parts, s.getLeftSeparator(previous, widget), s.renderSegment(widget))
+	}
+	if len(widgets) > 0 {
+		parts = append(parts, s.getCap(widgets[len(widgets)-1], s.separatorStyle().Tail))
+	}
+	return strings.Join(parts, "")
+}
+
+// getCap returns a head or tail cap in the widget's background color, if the theme uses caps
+func (s *StatusLine) getCap(widget Widget, glyph string) string {
+	if !s.Theme.UsePowerline || !s.Theme.Caps || widget.BgColor == "" || glyph == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s%s%s", getBgToFgColor(widget.BgColor), glyph, ColorReset)
+}
+
+// getLeftSeparator returns the separator leading into a right-aligned widget;
+// previous is nil for the first widget of the group
+func (s *StatusLine) getLeftSeparator(previous *Widget, current Widget) string {
+	style := s.separatorStyle()
+	if !s.Theme.UsePowerline {
+		if previous == nil {
+			return ""
+		}
+		return s.getPlainSeparator(style.LeftThin)
+	}
+
+	if current.BgColor != "" {
+		if previous != nil && previous.BgColor != "" {
+			// Background to background transition
+			return fmt.Sprintf("%s%s%s%s", previous.BgColor, getBgToFgColor(current.BgColor), style.Left, ColorReset)
+		}
+		// Normal to background transition
+		return fmt.Sprintf("%s%s%s", getBgToFgColor(current.BgColor), style.Left, ColorReset)
+	}
+	if previous == nil {
+		return ""
+	}
+	// Transition into a widget without background
+	return fmt.Sprintf(" %s%s%s ", s.Theme.SeparatorColor, style.LeftThin, ColorReset)
+}
+
+// getSeparator returns appropriate separator between two widgets
+func (s *StatusLine) getSeparator(current, next Widget) string {
+	style := s.separatorStyle()
+	if !s.Theme.UsePowerline {
+		return s.getPlainSeparator(style.RightThin)
+	}
+
+	// Powerline arrow separator
+	if current.BgColor != "" && next.BgColor != "" {
+		// Background to background transition
+		return fmt.Sprintf("%s%s%s%s", next.BgColor, getBgToFgColor(current.BgColor), style.Right, ColorReset)
+	} else if current.BgColor != "" && next.BgColor == "" {
+		// Background to normal transition
+		return fmt.Sprintf("%s%s%s", getBgToFgColor(current.BgColor), style.Right, ColorReset)
+	} else {
+		// Normal to normal transition
+		return fmt.Sprintf(" %s%s%s ", s.Theme.SeparatorColor, style.RightThin, ColorReset)
+	}
+}
+
+// getPlainSeparator returns the separator for themes without powerline segments;
+// they keep the classic pipe unless a separator style is chosen
+func (s *StatusLine) getPlainSeparator(thin string) string {
+	if s.Theme.SeparatorStyle == "" {
+		thin = "|"
+	}
+	return fmt.Sprintf(" %s%s%s ", s.Theme.SeparatorColor, thin, ColorReset)
+}
@@ -0,0 +1,245 @@
+package render
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mrdavidaylward/ccstatus/internal/debug"
+)
+
+// Widget format templates. Text outside braces is literal; inside:
+//
+//	{name}             field value
+//	{name|fmt|fmt2}    field value passed through formatters
+//	{?name}...{/name}  section shown only when the field is set (non-empty, non-zero)
+//	{!name}...{/name}  section shown only when the field is not set
+//	{{ and }}          literal braces
+
+// TemplateFields holds the values a template can reference. Values are strings,
+// ints, float64s, bools, time.Durations, or func() interface{} for values that
+// are expensive to compute and only evaluated when a template uses them.
+type TemplateFields map[string]interface{}
+
+// templateFormatters convert a field value for display
+var templateFormatters = map[string]func(v interface{}) string{
+	"tokens": func(v interface{}) string {
+		return formatTokensAdvanced(toInt(v))
+	},
+	"currency": func(v interface{}) string {
+		return formatCost(toFloat(v))
+	},
+	"duration": func(v interface{}) string {
+		if d, ok := v.(time.Duration); ok {
+			return formatDuration(d)
+		}
+		return formatDuration(time.Duration(toInt(v)) * time.Millisecond)
+	},
+	"percent": func(v interface{}) string {
+		if f, ok := v.(float64); ok {
+			return formatEfficiency(f)
+		}
+		return fmt.Sprintf("%d%%", toInt(v))
+	},
+	"int": func(v interface{}) string {
+		return strconv.Itoa(toInt(v))
+	},
+	"upper": func(v interface{}) string {
+		return strings.ToUpper(formatValue(v))
+	},
+	"lower": func(v interface{}) string {
+		return strings.ToLower(formatValue(v))
+	},
+}
+
+// With returns a copy of the fields with extra values added or replaced
+func (f TemplateFields) With(extra TemplateFields) TemplateFields {
+	merged := make(TemplateFields, len(f)+len(extra))
+	for name, value := range f {
+		merged[name] = value
+	}
+	for name, value := range extra {
+		merged[name] = value
+	}
+	return merged
+}
+
+// Get returns a field's value, evaluating lazy fields
+func (f TemplateFields) Get(name string) (interface{}, bool) {
+	value, ok := f[name]
+	if lazy, isLazy := value.(func() interface{}); isLazy {
+		value = lazy()
+	}
+	return value, ok
+}
+
+// RenderTemplate expands a format template with the given fields
+func RenderTemplate(tmpl string, fields TemplateFields) (string, error) {
+	var out strings.Builder
+	rest, err := expandTemplate(tmpl, fields, "", &out, true)
+	if err != nil {
+		return "", err
+	}
+	if rest != "" {
+		return "", fmt.Errorf("unexpected %q", rest)
+	}
+	return out.String(), nil
+}
+
+// expandTemplate writes tmpl to out (when emit is set) until the closing tag of
+// section, returning the text after that tag
+func expandTemplate(tmpl string, fields TemplateFields, section string, out *strings.Builder, emit bool) (string, error) {
+	for tmpl != "" {
+		switch {
+		case strings.HasPrefix(tmpl, "{{"):
+			if emit {
+				out.WriteByte('{')
+			}
+			tmpl = tmpl[2:]
+			continue
+		case strings.HasPrefix(tmpl, "}}"):
+			if emit {
+				out.WriteByte('}')
+			}
+			tmpl = tmpl[2:]
+			continue
+		case tmpl[0] != '{':
+			next := strings.IndexAny(tmpl, "{}")
+			if next < 0 {
+				next = len(tmpl)
+			} else if next == 0 {
+				return "", fmt.Errorf("unmatched '}'")
+			}
+			if emit {
+				out.WriteString(tmpl[:next])
+			}
+			tmpl = tmpl[next:]
+			continue
+		}
+
+		end := strings.IndexByte(tmpl, '}')
+		if end < 0 {
+			return "", fmt.Errorf("unclosed '{'")
+		}
+		tag, after := tmpl[1:end], tmpl[end+1:]
+
+		switch {
+		case strings.HasPrefix(tag, "/"):
+			if name := tag[1:]; name != section {
+				return "", fmt.Errorf("unexpected {/%s}", name)
+			}
+			return after, nil
+
+		case strings.HasPrefix(tag, "?"), strings.HasPrefix(tag, "!"):
+			name := tag[1:]
+			value, _ := fields.Get(name)
+			show := isTruthy(value) == (tag[0] == '?')
+			rest, err := expandTemplate(after, fields, name, out, emit && show)
+			if err != nil {
+				return "", err
+			}
+			tmpl = rest
+			continue
+
+		default:
+			parts := strings.Split(tag, "|")
+			name := strings.TrimSpace(parts[0])
+			if name == "" {
+				return "", fmt.Errorf("empty field name")
+			}
+			value, ok := fields.Get(name)
+			if !ok {
+				debug.Log("Unknown template field %q", name)
+			}
+			text := formatValue(value)
+			for _, formatterName := range parts[1:] {
+				formatter, ok := templateFormatters[strings.TrimSpace(formatterName)]
+				if !ok {
+					return "", fmt.Errorf("unknown formatter %q", formatterName)
+				}
+				text = formatter(value)
+				value = text
+			}
+			if emit {
+				out.WriteString(text)
+			}
+			tmpl = after
+		}
+	}
+
+	if section != "" {
+		return "", fmt.Errorf("missing {/%s}", section)
+	}
+	return "", nil
+}
+
+// formatValue renders a field value without formatters
+func formatValue(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case int:
+		return strconv.Itoa(value)
+	case float64:
+		return strconv.FormatFloat(value, 'f', 2, 64)
+	case time.Duration:
+		return formatDuration(value)
+	case bool:
+		if value {
+			return "true"
+		}
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// isTruthy reports whether a field value counts as set for conditionals
+func isTruthy(v interface{}) bool {
+	switch value := v.(type) {
+	case nil:
+		return false
+	case string:
+		return value != ""
+	case int:
+		return value != 0
+	case float64:
+		return value != 0
+	case time.Duration:
+		return value != 0
+	case bool:
+		return value
+	}
+	return true
+}
+
+func toInt(v interface{}) int {
+	switch value := v.(type) {
+	case int:
+		return value
+	case float64:
+		return int(math.Round(value))
+	case time.Duration:
+		return int(value / time.Millisecond)
+	case string:
+		n, _ := strconv.Atoi(value)
+		return n
+	}
+	return 0
+}
+
+func toFloat(v interface{}) float64 {
+	switch value := v.(type) {
+	case int:
+		return float64(value)
+	case float64:
+		return value
+	case string:
+		f, _ := strconv.ParseFloat(value, 64)
+		return f
+	}
+	return 0
+}
@@ -0,0 +1,124 @@
+package render
+
+import (
+	"testing"
+	"time"
+)
+
+// TestRenderTemplate tests fields, formatters, conditional sections and escapes
+func TestRenderTemplate(t *testing.T) {
+	calls := 0
+	fields := TemplateFields{
+		"icon":     "M",
+		"used":     12,
+		"limit":    45,
+		"tokens":   1234567,
+		"cost":     1.5,
+		"duration": 125 * time.Minute,
+		"branch":   "main",
+		"changes":  0,
+		"model":    "Opus",
+		"lazy": func() interface{} {
+			calls++
+			return "computed"
+		},
+	}
+
+	tests := []struct {
+		name string
+		tmpl string
+		want string
+	}{
+		{name: "plain text", tmpl: "hello", want: "hello"},
+		{name: "fields", tmpl: "{icon} {used}/{limit}", want: "M 12/45"},
+		{name: "tokens formatter", tmpl: "{tokens|tokens}", want: "1.2M"},
+		{name: "currency formatter", tmpl: "{cost|currency}", want: "$1.50"},
+		{name: "duration value", tmpl: "{duration}", want: "2h 5m"},
+		{name: "chained formatters", tmpl: "{model|lower|upper}", want: "OPUS"},
+		{name: "int formatter", tmpl: "{cost|int}", want: "2"},
+		{name: "section shown", tmpl: "{branch}{?branch}!{/branch}", want: "main!"},
+		{name: "section hidden", tmpl: "{branch}{?changes}±{changes}{/changes}", want: "main"},
+		{name: "inverted section", tmpl: "{!changes}clean{/changes}", want: "clean"},
+		{name: "nested sections", tmpl: "{?branch}[{?changes}dirty{/changes}{!changes}ok{/changes}]{/branch}", want: "[ok]"},
+		{name: "unknown field", tmpl: "a{nope}b", want: "ab"},
+		{name: "escaped braces", tmpl: "{{{used}}}", want: "{12}"},
+		{name: "lazy field", tmpl: "{lazy}", want: "computed"},
+		{name: "lazy field in hidden section", tmpl: "{?changes}{lazy}{/changes}", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderTemplate(tt.tmpl, fields)
+			if err != nil {
+				t.Fatalf("RenderTemplate(%q) error: %v", tt.tmpl, err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderTemplate(%q) = %q, want %q", tt.tmpl, got, tt.want)
+			}
+		})
+	}
+
+	if calls != 2 {
+		t.Errorf("lazy field evaluated %d times, want 2", calls)
+	}
+}
+
+// TestRenderTemplateErrors tests malformed templates
+func TestRenderTemplateErrors(t *testing.T) {
+	tests := []string{
+		"{used",
+		"used}",
+		"{}",
+		"{used|bogus}",
+		"{?used}open",
+		"{?used}x{/limit}",
+		"x{/used}",
+	}
+
+	for _, tmpl := range tests {
+		if _, err := RenderTemplate(tmpl, TemplateFields{"used": 1}); err == nil {
+			t.Errorf("RenderTemplate(%q) expected error", tmpl)
+		}
+	}
+}
+
+// TestAddFormattedWidget tests format overrides, fallbacks and empty output
+func TestAddFormattedWidget(t *testing.T) {
+	fields := TemplateFields{"used": 3, "limit": 45, "icon": ""}
+
+	tests := []struct {
+		name        string
+		opts        WidgetOptions
+		wantContent string
+		wantCompact string
+		wantAdded   bool
+	}{
+		{name: "default format", opts: nil, wantContent: "3/45", wantCompact: "3", wantAdded: true},
+		{name: "format override", opts: WidgetOptions{"format": "{used} msgs"}, wantContent: "3 msgs", wantCompact: "3", wantAdded: true},
+		{name: "compact override", opts: WidgetOptions{"compact_format": "#{used}"}, wantContent: "3/45", wantCompact: "#3", wantAdded: true},
+		{name: "invalid format falls back", opts: WidgetOptions{"format": "{used"}, wantContent: "3/45", wantCompact: "3", wantAdded: true},
+		{name: "empty output skipped", opts: WidgetOptions{"format": "{?nope}x{/nope}"}, wantAdded: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &StatusLine{}
+			s.AddFormattedWidget("messages", tt.opts, "{icon} {used}/{limit}", "{used}", fields, "", "")
+			if !tt.wantAdded {
+				if len(s.Widgets) != 0 {
+					t.Errorf("expected no widget, got %q", s.Widgets[0].Content)
+				}
+				return
+			}
+			if len(s.Widgets) != 1 {
+				t.Fatalf("got %d widgets, want 1", len(s.Widgets))
+			}
+			if got := s.Widgets[0].Content; got != tt.wantContent {
+				t.Errorf("Content = %q, want %q", got, tt.wantContent)
+			}
+			if got := s.Widgets[0].Compact; got != tt.wantCompact {
+				t.Errorf("Compact = %q, want %q", got, tt.wantCompact)
+			}
+		})
+	}
+}
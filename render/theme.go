@@ -0,0 +1,267 @@
+package render
+
+import (
+	"fmt"
+)
+
+// Powerline symbols
+const (
+	PowerlineRightArrow     = "\uE0B0" //
+	PowerlineRightThinArrow = "\uE0B1" //
+	PowerlineLeftArrow      = "\uE0B2" //
+	PowerlineLeftThinArrow  = "\uE0B3" //
+	GitBranch               = "\uE0A0" //
+	GitIcon                 = "𖠰"
+	BlockIcon               = "⏱"
+	TokenIcon               = "🔤"
+	TimerIcon               = "⏱"
+	PercentIcon             = "%"
+	DollarIcon              = "$"
+	MessageIcon             = "💬"
+	DurationIcon            = "⌛"
+	EfficiencyIcon          = "📊"
+	LatencyIcon             = "⚡"
+	CompactionIcon          = "🗜️"
+	WeeklyIcon              = "📅"
+	DailyIcon               = "📊"
+)
+
+// Enhanced ANSI color codes with truecolor support
+const (
+	ColorReset     = "\033[0m"
+	ColorBold      = "\033[1m"
+	ColorDim       = "\033[2m"
+	ColorDefaultFg = "\033[39m" // Restores the default foreground, leaving the background alone
+
+	// Standard colors
+	ColorBlack   = "\033[30m"
+	ColorRed     = "\033[31m"
+	ColorGreen   = "\033[32m"
+	ColorYellow  = "\033[33m"
+	ColorBlue    = "\033[34m"
+	ColorMagenta = "\033[35m"
+	ColorCyan    = "\033[36m"
+	ColorWhite   = "\033[37m"
+
+	// Bright colors
+	ColorBrightBlack   = "\033[90m"
+	ColorBrightRed     = "\033[91m"
+	ColorBrightGreen   = "\033[92m"
+	ColorBrightYellow  = "\033[93m"
+	ColorBrightBlue    = "\033[94m"
+	ColorBrightMagenta = "\033[95m"
+	ColorBrightCyan    = "\033[96m"
+	ColorBrightWhite   = "\033[97m"
+
+	// Background colors
+	BgBlack   = "\033[40m"
+	BgRed     = "\033[41m"
+	BgGreen   = "\033[42m"
+	BgYellow  = "\033[43m"
+	BgBlue    = "\033[44m"
+	BgMagenta = "\033[45m"
+	BgCyan    = "\033[46m"
+	BgWhite   = "\033[47m"
+
+	// Bright background colors
+	BgBrightBlack   = "\033[100m"
+	BgBrightRed     = "\033[101m"
+	BgBrightGreen   = "\033[102m"
+	BgBrightYellow  = "\033[103m"
+	BgBrightBlue    = "\033[104m"
+	BgBrightMagenta = "\033[105m"
+	BgBrightCyan    = "\033[106m"
+	BgBrightWhite   = "\033[107m"
+)
+
+// Theme configuration
+type Theme struct {
+	Name           string
+	Styles         map[string]WidgetStyle // Keyed by widget name or by a shared style such as "time"
+	SeparatorColor string
+	SeparatorStyle string // Key in separatorStyles; empty for the default
+	Caps           bool   // Close each group with the style's head and tail caps
+	UsePowerline   bool
+}
+
+// WidgetStyle colors a widget, optionally switching colors on a percentage
+type WidgetStyle struct {
+	Fg         string
+	Bg         string
+	Thresholds []StyleThreshold // Checked in order; the first match wins, otherwise Fg/Bg apply
+	Fallback   string           // Theme style to use when the theme has none for the widget
+}
+
+// StyleThreshold applies its colors to values below Below
+type StyleThreshold struct {
+	Below int
+	Fg    string
+	Bg    string
+}
+
+// Colors returns the foreground and background for a percentage
+func (st WidgetStyle) Colors(level int) (fg, bg string) {
+	for _, th := range st.Thresholds {
+		if level < th.Below {
+			return th.Fg, th.Bg
+		}
+	}
+	return st.Fg, st.Bg
+}
+
+// Style returns the theme's style for a widget, then the theme style named by
+// def.Fallback, then def itself
+func (t Theme) Style(name string, def WidgetStyle) WidgetStyle {
+	if style, ok := t.Styles[name]; ok {
+		return style
+	}
+	if style, ok := t.Styles[def.Fallback]; ok && def.Fallback != "" {
+		return style
+	}
+	return def
+}
+
+// Predefined themes
+var themes = map[string]Theme{
+	"powerline": {
+		Name: "Powerline",
+		Styles: map[string]WidgetStyle{
+			"user":  {Fg: ColorBrightWhite, Bg: BgBlue},
+			"host":  {Fg: ColorBrightWhite, Bg: BgBlue},
+			"path":  {Fg: ColorBlack, Bg: BgBrightCyan},
+			"model": {Fg: ColorBrightWhite, Bg: BgMagenta},
+			"percent": {Fg: ColorBlack, Bg: BgGreen, Thresholds: []StyleThreshold{
+				{Below: 10, Fg: ColorBrightWhite, Bg: BgRed},
+				{Below: 30, Fg: ColorBlack, Bg: BgYellow},
+			}},
+			"tokens": {Fg: ColorBrightWhite, Bg: BgBrightBlack},
+			"time":   {Fg: ColorBrightWhite, Bg: BgBrightBlue},
+			"git":    {Fg: ColorBrightWhite, Bg: BgBrightGreen},
+			// Counts inside the git segment; only the foreground is used
+			"git_conflicted": {Fg: ColorRed},
+			"git_staged":     {Fg: ColorBlack},
+			"git_modified":   {Fg: ColorBlue},
+			"git_untracked":  {Fg: ColorBrightWhite},
+			"git_stashed":    {Fg: ColorMagenta},
+			"cost":           {Fg: ColorBrightWhite, Bg: BgRed},
+			"messages":       {Fg: ColorBrightWhite, Bg: BgMagenta},
+			"efficiency":     {Fg: ColorBrightWhite, Bg: BgBrightBlue},
+			"latency":        {Fg: ColorBrightWhite, Bg: BgBrightGreen},
+			// Upstream and operation parts of the git segment
+			"git_ahead":       {Fg: ColorBrightWhite},
+			"git_behind":      {Fg: ColorBrightWhite},
+			"git_no_upstream": {Fg: ColorBlack},
+			"git_operation":   {Fg: ColorRed},
+			"compaction": {Fg: ColorBrightWhite, Bg: BgRed, Thresholds: []StyleThreshold{
+				{Below: 50, Fg: ColorBrightWhite, Bg: BgGreen},
+				{Below: 80, Fg: ColorBlack, Bg: BgYellow},
+			}},
+			"weekly": {Fg: ColorBrightWhite, Bg: BgRed, Thresholds: []StyleThreshold{
+				{Below: 60, Fg: ColorBrightWhite, Bg: BgBrightBlue},
+				{Below: 85, Fg: ColorBlack, Bg: BgYellow},
+			}},
+		},
+		SeparatorColor: ColorReset,
+		UsePowerline:   true,
+	},
+	"minimal": {
+		Name: "Minimal",
+		Styles: map[string]WidgetStyle{
+			"user":  {Fg: ColorBrightGreen},
+			"host":  {Fg: ColorBrightGreen},
+			"path":  {Fg: ColorBrightBlue},
+			"model": {Fg: ColorBrightMagenta},
+			"percent": {Fg: ColorBrightGreen, Thresholds: []StyleThreshold{
+				{Below: 10, Fg: ColorBrightRed},
+				{Below: 30, Fg: ColorBrightYellow},
+			}},
+			"tokens": {Fg: ColorBrightBlack},
+			"time":   {Fg: ColorBrightCyan},
+			"git":    {Fg: ColorBrightYellow},
+			// Counts inside the git segment; only the foreground is used
+			"git_conflicted": {Fg: ColorBrightRed},
+			"git_staged":     {Fg: ColorBrightGreen},
+			"git_modified":   {Fg: ColorBrightYellow},
+			"git_untracked":  {Fg: ColorBrightBlack},
+			"git_stashed":    {Fg: ColorBrightMagenta},
+			"cost":           {Fg: ColorBrightRed},
+			"messages":       {Fg: ColorBrightMagenta},
+			"efficiency":     {Fg: ColorBrightBlue},
+			"latency":        {Fg: ColorBrightGreen},
+			// Upstream and operation parts of the git segment
+			"git_ahead":       {Fg: ColorBrightCyan},
+			"git_behind":      {Fg: ColorBrightCyan},
+			"git_no_upstream": {Fg: ColorBrightBlack},
+			"git_operation":   {Fg: ColorBrightRed},
+			"compaction": {Fg: ColorBrightRed, Thresholds: []StyleThreshold{
+				{Below: 50, Fg: ColorBrightGreen},
+				{Below: 80, Fg: ColorBrightYellow},
+			}},
+			"weekly": {Fg: ColorBrightRed, Thresholds: []StyleThreshold{
+				{Below: 60, Fg: ColorBrightBlue},
+				{Below: 85, Fg: ColorBrightYellow},
+			}},
+		},
+		SeparatorColor: ColorBrightBlack,
+		UsePowerline:   false,
+	},
+	"gruvbox": {
+		Name: "Gruvbox",
+		Styles: map[string]WidgetStyle{
+			"user":  {Fg: trueColor(254, 128, 25), Bg: trueColorBg(40, 40, 40)},   // orange on dark gray
+			"host":  {Fg: trueColor(184, 187, 38), Bg: trueColorBg(60, 56, 54)},   // yellow-green on gray
+			"path":  {Fg: trueColor(131, 165, 152), Bg: trueColorBg(80, 73, 69)},  // aqua on darker gray
+			"model": {Fg: trueColor(211, 134, 155), Bg: trueColorBg(102, 92, 84)}, // purple on brown-gray
+			"percent": {Fg: trueColor(184, 187, 38), Bg: trueColorBg(60, 56, 54), Thresholds: []StyleThreshold{
+				{Below: 10, Fg: trueColor(251, 73, 52), Bg: trueColorBg(60, 56, 54)},  // red
+				{Below: 30, Fg: trueColor(250, 189, 47), Bg: trueColorBg(60, 56, 54)}, // yellow
+			}},
+			"tokens": {Fg: trueColor(235, 219, 178), Bg: trueColorBg(50, 48, 47)}, // light on darker
+			"time":   {Fg: trueColor(142, 192, 124), Bg: trueColorBg(40, 40, 40)}, // bright green
+			"git":    {Fg: trueColor(254, 128, 25), Bg: trueColorBg(60, 56, 54)},  // orange
+			// Counts inside the git segment; only the foreground is used
+			"git_conflicted": {Fg: trueColor(251, 73, 52)},                                // red
+			"git_staged":     {Fg: trueColor(184, 187, 38)},                               // green
+			"git_modified":   {Fg: trueColor(250, 189, 47)},                               // yellow
+			"git_untracked":  {Fg: trueColor(168, 153, 132)},                              // gray
+			"git_stashed":    {Fg: trueColor(211, 134, 155)},                              // purple
+			"cost":           {Fg: trueColor(251, 73, 52), Bg: trueColorBg(40, 40, 40)},   // red
+			"messages":       {Fg: trueColor(211, 134, 155), Bg: trueColorBg(60, 56, 54)}, // purple
+			"efficiency":     {Fg: trueColor(131, 165, 152), Bg: trueColorBg(80, 73, 69)}, // aqua
+			"latency":        {Fg: trueColor(142, 192, 124), Bg: trueColorBg(50, 48, 47)}, // bright green
+			// Upstream and operation parts of the git segment
+			"git_ahead":       {Fg: trueColor(131, 165, 152)}, // aqua
+			"git_behind":      {Fg: trueColor(131, 165, 152)},
+			"git_no_upstream": {Fg: trueColor(168, 153, 132)},
+			"git_operation":   {Fg: trueColor(251, 73, 52)}, // red
+			"compaction": {Fg: trueColor(251, 73, 52), Bg: trueColorBg(60, 56, 54), Thresholds: []StyleThreshold{
+				{Below: 50, Fg: trueColor(142, 192, 124), Bg: trueColorBg(60, 56, 54)}, // bright green
+				{Below: 80, Fg: trueColor(250, 189, 47), Bg: trueColorBg(60, 56, 54)},  // yellow
+			}},
+			"weekly": {Fg: trueColor(251, 73, 52), Bg: trueColorBg(60, 56, 54), Thresholds: []StyleThreshold{
+				{Below: 60, Fg: trueColor(131, 165, 152), Bg: trueColorBg(60, 56, 54)}, // aqua
+				{Below: 85, Fg: trueColor(250, 189, 47), Bg: trueColorBg(60, 56, 54)},  // yellow
+			}},
+		},
+		SeparatorColor: trueColor(80, 73, 69),
+		UsePowerline:   true,
+	},
+}
+
+// trueColor returns truecolor ANSI sequence
+func trueColor(r, g, b int) string {
+	return fmt.Sprintf("\033[38;2;%d;%d;%dm", r, g, b)
+}
+
+// trueColorBg returns truecolor background ANSI sequence
+func trueColorBg(r, g, b int) string {
+	return fmt.Sprintf("\033[48;2;%d;%d;%dm", r, g, b)
+}
+
+// getBgToFgColor converts background color code to foreground
+func getBgToFgColor(bgColor string) string {
+	if c, ok := colorFromANSI(bgColor); ok {
+		return c.Fg()
+	}
+	return ColorWhite
+}
@@ -0,0 +1,101 @@
+package render
+
+import (
+	"testing"
+)
+
+// TestGetBgToFgColor tests background to foreground color conversion
+func TestGetBgToFgColor(t *testing.T) {
+	tests := []struct {
+		name    string
+		bgColor string
+		want    string
+	}{
+		{
+			name:    "standard blue",
+			bgColor: BgBlue,
+			want:    ColorBlue,
+		},
+		{
+			name:    "truecolor conversion",
+			bgColor: "\033[48;2;60;56;54m",
+			want:    "\033[38;2;60;56;54m",
+		},
+		{
+			name:    "bright background",
+			bgColor: BgBrightMagenta,
+			want:    ColorBrightMagenta,
+		},
+		{
+			name:    "cyan background",
+			bgColor: BgCyan,
+			want:    ColorCyan,
+		},
+		{
+			name:    "256-color conversion",
+			bgColor: "\033[48;5;208m",
+			want:    "\033[38;5;208m",
+		},
+		{
+			name:    "unknown color",
+			bgColor: "\033[49m",
+			want:    ColorWhite,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getBgToFgColor(tt.bgColor)
+			if got != tt.want {
+				t.Errorf("getBgToFgColor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestThemeStyle tests style lookup by widget name with fallbacks
+func TestThemeStyle(t *testing.T) {
+	theme := themes["powerline"]
+	tests := []struct {
+		name   string
+		widget string
+		def    WidgetStyle
+		wantBg string
+	}{
+		{name: "own style", widget: "git", def: WidgetStyle{Bg: BgCyan}, wantBg: BgBrightGreen},
+		{name: "fallback style", widget: "duration", def: WidgetStyle{Bg: BgCyan, Fallback: "time"}, wantBg: BgBrightBlue},
+		{name: "default", widget: "latency_p99", def: WidgetStyle{Bg: BgCyan, Fallback: "nope"}, wantBg: BgCyan},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := theme.Style(tt.widget, tt.def).Bg; got != tt.wantBg {
+				t.Errorf("Style(%q) bg = %q, want %q", tt.widget, got, tt.wantBg)
+			}
+		})
+	}
+}
+
+// TestWidgetStyleColors tests threshold selection
+func TestWidgetStyleColors(t *testing.T) {
+	style := themes["powerline"].Styles["compaction"]
+	for _, tt := range []struct {
+		level  int
+		wantBg string
+	}{{10, BgGreen}, {50, BgYellow}, {79, BgYellow}, {95, BgRed}} {
+		if _, got := style.Colors(tt.level); got != tt.wantBg {
+			t.Errorf("Colors(%d) bg = %q, want %q", tt.level, got, tt.wantBg)
+		}
+	}
+}
+
+// TestThemesStyleGitParts tests that every built-in theme colors every git status part
+func TestThemesStyleGitParts(t *testing.T) {
+	for name, theme := range themes {
+		for _, part := range append(gitStatusParts, "operation") {
+			if _, ok := theme.Styles["git_"+part]; !ok {
+				t.Errorf("theme %s has no git_%s style", name, part)
+			}
+		}
+	}
+}
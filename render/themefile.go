@@ -0,0 +1,214 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mrdavidaylward/ccstatus/internal/debug"
+	"github.com/mrdavidaylward/ccstatus/schema"
+)
+
+// ThemeFile is the on-disk JSON representation of a Theme
+type ThemeFile struct {
+	Name         string               `json:"name"`
+	Extends      string               `json:"extends,omitempty"`
+	UsePowerline *bool                `json:"use_powerline,omitempty"`
+	Separator    string               `json:"separator,omitempty"`
+	SepStyle     string               `json:"separator_style,omitempty"`
+	Caps         *bool                `json:"caps,omitempty"`
+	Colors       map[string]ColorSpec `json:"colors,omitempty"`
+}
+
+// ColorSpec sets a widget's colors, optionally switching on a threshold table
+type ColorSpec struct {
+	Fg         string           `json:"fg,omitempty"`
+	Bg         string           `json:"bg,omitempty"`
+	Thresholds []ColorThreshold `json:"thresholds,omitempty"`
+}
+
+// ColorThreshold applies its colors when the value is below Below (or always if Below is unset)
+type ColorThreshold struct {
+	Below *int   `json:"below,omitempty"`
+	Fg    string `json:"fg,omitempty"`
+	Bg    string `json:"bg,omitempty"`
+}
+
+// maxThemeExtendsDepth guards against cycles in theme inheritance
+const maxThemeExtendsDepth = 8
+
+// getThemesDir returns the directory searched for user theme files
+func getThemesDir(env schema.Env) string {
+	configDir := getConfigDir(env)
+	if configDir == "" {
+		return ""
+	}
+	return filepath.Join(configDir, "themes")
+}
+
+// LoadTheme resolves a theme by built-in name, user theme name or file path
+func LoadTheme(name string, env schema.Env) (Theme, error) {
+	return loadThemeDepth(name, env, 0)
+}
+
+func loadThemeDepth(name string, env schema.Env, depth int) (Theme, error) {
+	if depth > maxThemeExtendsDepth {
+		return Theme{}, fmt.Errorf("theme %q: too many levels of extends", name)
+	}
+
+	if theme, ok := themes[name]; ok {
+		return theme, nil
+	}
+
+	path := name
+	if !strings.HasSuffix(name, ".json") && !strings.ContainsRune(name, filepath.Separator) {
+		themesDir := getThemesDir(env)
+		if themesDir == "" {
+			return Theme{}, fmt.Errorf("unknown theme %q", name)
+		}
+		path = filepath.Join(themesDir, name+".json")
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Theme{}, fmt.Errorf("unknown theme %q", name)
+		}
+		return Theme{}, err
+	}
+
+	var file ThemeFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return Theme{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	base := themes["powerline"]
+	if file.Extends != "" {
+		if base, err = loadThemeDepth(file.Extends, env, depth+1); err != nil {
+			return Theme{}, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	theme, err := file.apply(base)
+	if err != nil {
+		return Theme{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	debug.Log("Loaded theme %q from %s", theme.Name, path)
+	return theme, nil
+}
+
+// apply overlays the theme file's settings onto base
+func (f ThemeFile) apply(base Theme) (Theme, error) {
+	theme := base
+	if f.Name != "" {
+		theme.Name = f.Name
+	}
+	if f.UsePowerline != nil {
+		theme.UsePowerline = *f.UsePowerline
+	}
+	if f.Separator != "" {
+		sep, err := ParseColor(f.Separator, false)
+		if err != nil {
+			return Theme{}, fmt.Errorf("separator: %w", err)
+		}
+		theme.SeparatorColor = sep
+	}
+	if f.SepStyle != "" {
+		if _, ok := separatorStyles[f.SepStyle]; !ok {
+			return Theme{}, fmt.Errorf("separator_style: unknown style %q", f.SepStyle)
+		}
+		theme.SeparatorStyle = f.SepStyle
+	}
+	if f.Caps != nil {
+		theme.Caps = *f.Caps
+	}
+
+	if len(f.Colors) > 0 {
+		// Copy so overrides never leak into the base theme
+		styles := make(map[string]WidgetStyle, len(base.Styles)+len(f.Colors))
+		for key, style := range base.Styles {
+			styles[key] = style
+		}
+		for key, spec := range f.Colors {
+			if !knownStyle(base, key) {
+				return Theme{}, fmt.Errorf("colors.%s: unknown widget", key)
+			}
+			style, err := spec.style()
+			if err != nil {
+				return Theme{}, fmt.Errorf("colors.%s: %w", key, err)
+			}
+			styles[key] = style
+		}
+		theme.Styles = styles
+	}
+
+	return theme, nil
+}
+
+// parse converts the fixed fg/bg pair to ANSI sequences
+func (c ColorSpec) parse() (fg, bg string, err error) {
+	if fg, err = ParseColor(c.Fg, false); err != nil {
+		return "", "", err
+	}
+	if bg, err = ParseColor(c.Bg, true); err != nil {
+		return "", "", err
+	}
+	return fg, bg, nil
+}
+
+// style converts the spec and its threshold table to a WidgetStyle
+func (c ColorSpec) style() (WidgetStyle, error) {
+	fg, bg, err := c.parse()
+	if err != nil {
+		return WidgetStyle{}, err
+	}
+
+	style := WidgetStyle{Fg: fg, Bg: bg}
+	for i, th := range c.Thresholds {
+		fg, bg, err := ColorSpec{Fg: th.Fg, Bg: th.Bg}.parse()
+		if err != nil {
+			return WidgetStyle{}, fmt.Errorf("thresholds[%d]: %w", i, err)
+		}
+		below := math.MaxInt
+		if th.Below != nil {
+			below = *th.Below
+		}
+		style.Thresholds = append(style.Thresholds, StyleThreshold{Below: below, Fg: fg, Bg: bg})
+	}
+	return style, nil
+}
+
+// variantStyles are styles widgets render under besides their own name: weekly shows
+// as daily when the daily limit is closer
+var variantStyles = map[string]bool{"daily": true}
+
+// knownStyle reports whether a theme file may color key: a registered widget, a
+// widget variant, a style some widget falls back to, or a style the base theme
+// already defines
+func knownStyle(base Theme, key string) bool {
+	if _, ok := base.Styles[key]; ok || variantStyles[key] {
+		return true
+	}
+	for name, provider := range widgetProviders {
+		if name == key || provider.DefaultStyle().Fallback == key {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseColor converts a color spec (see parseColorSpec) to an ANSI sequence
+func ParseColor(spec string, background bool) (string, error) {
+	c, err := parseColorSpec(spec)
+	if err != nil {
+		return "", err
+	}
+	if background {
+		return c.Bg(), nil
+	}
+	return c.Fg(), nil
+}
@@ -0,0 +1,157 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestParseColor tests color spec parsing
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		name       string
+		spec       string
+		background bool
+		want       string
+		wantErr    bool
+	}{
+		{name: "empty", spec: "", want: ""},
+		{name: "default", spec: "default", want: ""},
+		{name: "named fg", spec: "blue", want: ColorBlue},
+		{name: "named bg", spec: "blue", background: true, want: BgBlue},
+		{name: "bright underscore", spec: "bright_cyan", background: true, want: BgBrightCyan},
+		{name: "bright joined", spec: "BrightWhite", want: ColorBrightWhite},
+		{name: "256 fg", spec: "208", want: "\033[38;5;208m"},
+		{name: "256 bg", spec: "208", background: true, want: "\033[48;5;208m"},
+		{name: "hex", spec: "#fe8019", want: "\033[38;2;254;128;25m"},
+		{name: "short hex bg", spec: "#f80", background: true, want: "\033[48;2;255;136;0m"},
+		{name: "unknown name", spec: "chartreuse", wantErr: true},
+		{name: "index out of range", spec: "300", wantErr: true},
+		{name: "bad hex", spec: "#12345", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseColor(tt.spec, tt.background)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseColor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseColor() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestLoadThemeFile tests loading a user theme with extends and thresholds
+func TestLoadThemeFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "acme.json")
+	content := `{
+		"name": "Acme",
+		"extends": "minimal",
+		"use_powerline": true,
+		"separator_style": "rounded",
+		"caps": true,
+		"colors": {
+			"user": {"fg": "#ffffff", "bg": "#0050a0"},
+			"percent": {
+				"bg": "green",
+				"thresholds": [
+					{"below": 10, "fg": "bright_white", "bg": "red"},
+					{"below": 30, "fg": "black", "bg": "yellow"}
+				]
+			}
+		}
+	}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	theme, err := LoadTheme(path, nil)
+	if err != nil {
+		t.Fatalf("LoadTheme() error = %v", err)
+	}
+
+	if theme.Name != "Acme" || !theme.UsePowerline {
+		t.Errorf("LoadTheme() name = %v, powerline = %v", theme.Name, theme.UsePowerline)
+	}
+	if theme.SeparatorStyle != "rounded" || !theme.Caps {
+		t.Errorf("LoadTheme() separator style = %q, caps = %v", theme.SeparatorStyle, theme.Caps)
+	}
+	if got := theme.Styles["user"].Bg; got != trueColorBg(0, 80, 160) {
+		t.Errorf("LoadTheme() user bg = %q", got)
+	}
+	// Inherited from minimal
+	if got := theme.Styles["path"].Fg; got != ColorBrightBlue {
+		t.Errorf("LoadTheme() path fg = %q, want inherited %q", got, ColorBrightBlue)
+	}
+	if got := themes["minimal"].Styles["user"].Bg; got != "" {
+		t.Errorf("LoadTheme() modified the base theme: minimal user bg = %q", got)
+	}
+
+	for _, tt := range []struct {
+		percent int
+		want    string
+	}{{5, BgRed}, {20, BgYellow}, {80, BgGreen}} {
+		if _, got := theme.Styles["percent"].Colors(tt.percent); got != tt.want {
+			t.Errorf("percent Colors(%d) bg = %q, want %q", tt.percent, got, tt.want)
+		}
+	}
+}
+
+// TestLoadThemeVariantStyles tests coloring styles widgets use besides their own name
+func TestLoadThemeVariantStyles(t *testing.T) {
+	dir := t.TempDir()
+	for name, extends := range map[string]string{"default base": "", "gruvbox base": "gruvbox"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, extends+"daily.json")
+			content := `{"extends": "` + extends + `", "colors": {"daily": {"fg": "red"}, "git_ahead": {"fg": "red"}}}`
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			theme, err := LoadTheme(path, nil)
+			if err != nil {
+				t.Fatalf("LoadTheme() error = %v", err)
+			}
+			for _, style := range []string{"daily", "git_ahead"} {
+				if got := theme.Style(style, WidgetStyle{}).Fg; got != ColorRed {
+					t.Errorf("%s fg = %q, want %q", style, got, ColorRed)
+				}
+			}
+		})
+	}
+}
+
+// TestLoadThemeErrors tests theme resolution failures
+func TestLoadThemeErrors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{name: "missing", path: filepath.Join(dir, "missing.json")},
+		{name: "unknown widget", path: write("widget.json", `{"colors": {"nope": {"fg": "red"}}}`)},
+		{name: "bad threshold color", path: write("threshold.json", `{"colors": {"git": {"thresholds": [{"fg": "nope"}]}}}`)},
+		{name: "unknown separator style", path: write("style.json", `{"separator_style": "zigzag"}`)},
+		{name: "bad color", path: write("color.json", `{"colors": {"git": {"fg": "nope"}}}`)},
+		{name: "extends cycle", path: write("cycle.json", `{"extends": "`+filepath.Join(dir, "cycle.json")+`"}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadTheme(tt.path, nil); err == nil {
+				t.Errorf("LoadTheme(%s) expected error", tt.path)
+			}
+		})
+	}
+}
@@ -0,0 +1,428 @@
+package render
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mrdavidaylward/ccstatus/internal/debug"
+	"github.com/mrdavidaylward/ccstatus/schema"
+	"github.com/mrdavidaylward/ccstatus/source"
+	"github.com/mrdavidaylward/ccstatus/usage"
+)
+
+// RenderContext holds the data collected once per render and shared by all widgets
+type RenderContext struct {
+	Input               schema.StatusLineInput
+	Model               usage.ActiveModel
+	CCUsage             usage.CCUsageData     // From ccusage or the native aggregator
+	Calculated          usage.CalculatedUsage // From ~/.claude/calculate-usage.sh
+	Transcript          usage.TranscriptUsage // This session's transcript
+	DailyTokens         int
+	SessionInputTokens  int
+	SessionOutputTokens int
+	ContextTokens       int
+	ContextChars        int
+
+	fields TemplateFields // Built on first use by Fields
+}
+
+// Built-in widgets; other packages add theirs with RegisterWidget
+func init() {
+	RegisterWidget("user", userWidget{templateWidget{name: "user", format: "{user}{?host}@{host}{/host}", compact: "{user}"}})
+	RegisterWidget("path", pathWidget{templateWidget{name: "path", format: "{path}", compact: "{dir}"}})
+	RegisterWidget("git", gitWidget{templateWidget{name: "git", icon: true,
+		format:  "{icon} {?worktree}{worktree}:{/worktree}{branch}{?progress} {progress}{/progress}{?status} {status}{/status}",
+		compact: "{branch}{?operation} {operation}{/operation}{?changes}±{changes}{/changes}",
+		show: func(s *StatusLine, ctx *RenderContext) bool {
+			branch, _ := s.Fields(ctx).Get("branch")
+			return branch != ""
+		}}})
+	RegisterWidget("model", templateWidget{name: "model", format: "{model}"})
+	RegisterWidget("percent", templateWidget{name: "percent", format: "{remaining_pct}%", level: "remaining_pct"})
+	RegisterWidget("weekly", weeklyWidget{})
+	RegisterWidget("tokens", templateWidget{name: "tokens", format: "{icon} {tokens|tokens}", compact: "{tokens|tokens}", icon: true,
+		show: func(s *StatusLine, ctx *RenderContext) bool { return ctx.DailyTokens > 0 }})
+	RegisterWidget("cost", templateWidget{name: "cost", format: "{icon} {cost|currency}", compact: "{cost|currency}", icon: true,
+		show: func(s *StatusLine, ctx *RenderContext) bool {
+			_, ok := s.sessionCost(ctx)
+			return ok
+		}})
+	RegisterWidget("duration", templateWidget{name: "duration", format: "{icon} {duration}", compact: "{duration}", icon: true,
+		style: WidgetStyle{Fallback: "time"},
+		show: func(s *StatusLine, ctx *RenderContext) bool {
+			return ctx.Input.Cost != nil && ctx.Input.Cost.TotalDurationMs > 0
+		}})
+	RegisterWidget("api_duration", templateWidget{name: "api_duration", format: "{icon} {api_duration}", compact: "{api_duration}", icon: true,
+		style: WidgetStyle{Fallback: "latency"},
+		show: func(s *StatusLine, ctx *RenderContext) bool {
+			return ctx.Input.Cost != nil && ctx.Input.Cost.TotalAPIDurationMs > 0
+		}})
+	RegisterWidget("lines", templateWidget{name: "lines", format: "+{lines_added} -{lines_removed}",
+		style: WidgetStyle{Fallback: "git"},
+		show: func(s *StatusLine, ctx *RenderContext) bool {
+			cost := ctx.Input.Cost
+			return cost != nil && (cost.TotalLinesAdded > 0 || cost.TotalLinesRemoved > 0)
+		}})
+	RegisterWidget("messages", messagesWidget{})
+	RegisterWidget("efficiency", templateWidget{name: "efficiency", format: "{icon} {efficiency|percent}", compact: "{efficiency|percent}", icon: true,
+		show: func(s *StatusLine, ctx *RenderContext) bool { return ctx.ContextTokens > 0 }})
+	RegisterWidget("compaction", templateWidget{name: "compaction", format: "{icon} {compaction_pct}%", compact: "{compaction_pct}%", icon: true,
+		level: "compaction_pct",
+		show:  func(s *StatusLine, ctx *RenderContext) bool { return ctx.ContextTokens > 0 }})
+	RegisterWidget("timer", templateWidget{name: "timer", format: "{icon} {block_elapsed}", compact: "{block_elapsed}", icon: true,
+		style: WidgetStyle{Fallback: "time"},
+		show: func(s *StatusLine, ctx *RenderContext) bool {
+			elapsed, _ := s.Fields(ctx).Get("block_elapsed")
+			return elapsed != ""
+		}})
+	RegisterWidget("reset", templateWidget{name: "reset", format: "{reset_type} reset {reset}", compact: "{reset}",
+		style: WidgetStyle{Fallback: "time"}})
+	RegisterWidget("custom", customWidget{})
+}
+
+// lazyField defers an expensive field until a template uses it, computing it at most once
+func lazyField(compute func() interface{}) func() interface{} {
+	var once sync.Once
+	var value interface{}
+	return func() interface{} {
+		once.Do(func() { value = compute() })
+		return value
+	}
+}
+
+// Fields returns the fields every widget format and the custom widget can use
+func (s *StatusLine) Fields(ctx *RenderContext) TemplateFields {
+	if ctx.fields != nil {
+		return ctx.fields
+	}
+
+	workspacePath := formatWorkspacePath(ctx.Input.WorkspacePath(), s.Env)
+	contextPct := 0
+	if ctx.ContextTokens > 0 {
+		contextPct = usage.UsagePercentage(0, ctx.ContextTokens, 0, ctx.Model.ContextLimit)
+	}
+	var duration, apiDuration time.Duration
+	var linesAdded, linesRemoved int
+	if cost := ctx.Input.Cost; cost != nil {
+		duration = time.Duration(cost.TotalDurationMs) * time.Millisecond
+		apiDuration = time.Duration(cost.TotalAPIDurationMs) * time.Millisecond
+		linesAdded, linesRemoved = cost.TotalLinesAdded, cost.TotalLinesRemoved
+	}
+
+	git := lazyField(func() interface{} {
+		info, _ := source.ReadGitInfo(ctx.Input.WorkspacePath(), s.Env)
+		return info
+	})
+	reset := lazyField(func() interface{} {
+		remaining, resetType := getNextReset(s.Env)
+		return [2]string{remaining, resetType}
+	})
+
+	ctx.fields = TemplateFields{
+		"user":             lazyField(func() interface{} { return source.Username() }),
+		"host":             lazyField(func() interface{} { return source.Hostname() }),
+		"path":             workspacePath,
+		"dir":              filepath.Base(workspacePath),
+		"model":            getModelDisplay(ctx.Input.Model),
+		"model_id":         ctx.Model.ID,
+		"context_tokens":   ctx.ContextTokens,
+		"context_limit":    ctx.Model.ContextLimit,
+		"max_output":       ctx.Model.Spec.MaxOutput,
+		"context_pct":      contextPct,
+		"remaining_pct":    getRemainingPercent(ctx),
+		"efficiency":       usage.ContextEfficiency(ctx.ContextTokens, ctx.Model.ContextLimit),
+		"compaction_pct":   usage.CompactionPercentage(ctx.ContextTokens, ctx.Model.ContextLimit),
+		"tokens":           ctx.DailyTokens,
+		"input_tokens":     ctx.SessionInputTokens,
+		"output_tokens":    ctx.SessionOutputTokens,
+		"messages":         usage.MessageCount(ctx.CCUsage, ctx.Calculated),
+		"message_limit":    usage.MessagesPerWindow,
+		"session_messages": ctx.Transcript.Messages,
+		"cost": lazyField(func() interface{} {
+			cost, _ := s.sessionCost(ctx)
+			return cost
+		}),
+		"duration":      duration,
+		"api_duration":  apiDuration,
+		"lines_added":   linesAdded,
+		"lines_removed": linesRemoved,
+		"branch":        func() interface{} { return git().(source.GitInfo).Branch },
+		"changes":       func() interface{} { return git().(source.GitInfo).Changes },
+		"worktree":      func() interface{} { return git().(source.GitInfo).Worktree },
+		"staged":        func() interface{} { return git().(source.GitInfo).Staged },
+		"modified":      func() interface{} { return git().(source.GitInfo).Modified },
+		"untracked":     func() interface{} { return git().(source.GitInfo).Untracked },
+		"conflicted":    func() interface{} { return git().(source.GitInfo).Conflicted },
+		"stashed":       func() interface{} { return git().(source.GitInfo).Stashed },
+		"upstream":      func() interface{} { return git().(source.GitInfo).Upstream },
+		"ahead":         func() interface{} { return git().(source.GitInfo).Ahead },
+		"behind":        func() interface{} { return git().(source.GitInfo).Behind },
+		"no_upstream":   func() interface{} { return git().(source.GitInfo).NoUpstream },
+		"operation":     func() interface{} { return git().(source.GitInfo).Operation.Name },
+		"step":          func() interface{} { return git().(source.GitInfo).Operation.Step },
+		"steps":         func() interface{} { return git().(source.GitInfo).Operation.Total },
+		"daily_pct":     usage.DailyUsagePercentage(ctx.DailyTokens),
+		"weekly_pct": lazyField(func() interface{} {
+			return usage.WeeklyUsagePercentage(usage.WeeklyTokensUsed(ctx.CCUsage, ctx.Calculated))
+		}),
+		"block_elapsed": lazyField(func() interface{} { return usage.BlockTimerDisplay(s.Env) }),
+		"reset":         func() interface{} { return reset().([2]string)[0] },
+		"reset_type":    func() interface{} { return reset().([2]string)[1] },
+		"session_id":    ctx.Input.SessionID,
+		"version":       Version,
+	}
+	return ctx.fields
+}
+
+// AddFormattedWidget renders a widget from its format and compact templates, which
+// the "format" and "compact_format" options override, and skips it if the text is empty
+func (s *StatusLine) AddFormattedWidget(name string, opts WidgetOptions, format, compact string, fields TemplateFields, color, bgColor string) {
+	content := expandWidgetFormat(name, opts.String("format", format), format, fields)
+	if content == "" {
+		return
+	}
+	compactContent := ""
+	if compactFormat := opts.String("compact_format", compact); compactFormat != "" {
+		compactContent = expandWidgetFormat(name, compactFormat, compact, fields)
+	}
+	s.AddWidgetCompact(name, content, compactContent, color, bgColor)
+}
+
+// expandWidgetFormat renders a widget template, falling back to the built-in one if it is invalid
+func expandWidgetFormat(name, format, fallback string, fields TemplateFields) string {
+	text, err := RenderTemplate(format, fields)
+	if err != nil {
+		debug.Log("Invalid format for %s widget: %v", name, err)
+		text, _ = RenderTemplate(fallback, fields)
+	}
+	return strings.TrimSpace(text)
+}
+
+// userWidget shows user@host, dropping the host when "show_host" is false
+type userWidget struct{ templateWidget }
+
+// Collect returns the shared fields, with an empty host when it is hidden
+func (w userWidget) Collect(s *StatusLine, ctx *RenderContext, opts WidgetOptions) (TemplateFields, bool) {
+	fields := s.Fields(ctx)
+	if !opts.Bool("show_host", true) {
+		fields = fields.With(TemplateFields{"host": ""})
+	}
+	return fields, true
+}
+
+// pathWidget shows the workspace path, truncated to "max_length" with the full one as {full_path}
+type pathWidget struct{ templateWidget }
+
+// Collect returns the shared fields with {path} truncated
+func (w pathWidget) Collect(s *StatusLine, ctx *RenderContext, opts WidgetOptions) (TemplateFields, bool) {
+	fields := s.Fields(ctx)
+	workspacePath, _ := fields.Get("path")
+	return fields.With(TemplateFields{
+		"full_path": workspacePath,
+		"path":      truncatePath(workspacePath.(string), opts.Int("max_length", 30)),
+	}), true
+}
+
+// gitStatusParts are the fields the git widget's {status} shows, in order: counts,
+// and flags shown as their icon alone. Each part's icon and theme style are named
+// "git_" plus the field name.
+var gitStatusParts = []string{"no_upstream", "ahead", "behind", "conflicted", "staged", "modified", "untracked", "stashed"}
+
+// gitWidget shows the branch, any operation in progress and a per-state summary of the working tree
+type gitWidget struct{ templateWidget }
+
+// Render adds {progress}, the operation and its step (REBASE 3/7) colored by the
+// git_operation style, and {status}, each nonzero count or set flag led by its icon
+// and colored by its theme style
+func (w gitWidget) Render(s *StatusLine, fields TemplateFields, opts WidgetOptions, style WidgetStyle) {
+	operation, _ := fields.Get("operation")
+	progress, _ := operation.(string)
+	if progress != "" {
+		step, _ := fields.Get("step")
+		steps, _ := fields.Get("steps")
+		if current, _ := step.(int); current > 0 {
+			total, _ := steps.(int)
+			progress += fmt.Sprintf(" %d/%d", current, total)
+		}
+		progress = s.inlineColor("git_operation", progress, style.Fg)
+	}
+
+	var parts []string
+	for _, name := range gitStatusParts {
+		value, _ := fields.Get(name)
+		part := s.Icon("git_" + name)
+		switch value := value.(type) {
+		case int:
+			if value <= 0 {
+				continue
+			}
+			part += strconv.Itoa(value)
+		case bool:
+			if !value {
+				continue
+			}
+		default:
+			continue
+		}
+
+		parts = append(parts, s.inlineColor("git_"+name, part, style.Fg))
+	}
+	fields = fields.With(TemplateFields{"progress": progress, "status": strings.Join(parts, " ")})
+	w.templateWidget.Render(s, fields, opts, style)
+}
+
+// inlineColor colors text inside a segment with the named theme style's foreground,
+// then restores the segment's own foreground
+func (s *StatusLine) inlineColor(styleName, text, segmentFg string) string {
+	partStyle := s.Theme.Style(styleName, WidgetStyle{})
+	if partStyle.Fg == "" {
+		return text
+	}
+	if segmentFg == "" {
+		segmentFg = ColorDefaultFg
+	}
+	return partStyle.Fg + text + segmentFg
+}
+
+// getRemainingPercent returns the remaining capacity shown by the percent widget
+func getRemainingPercent(ctx *RenderContext) int {
+	usagePercent := usage.UsagePercentage(ctx.DailyTokens, ctx.ContextTokens, ctx.ContextChars, ctx.Model.ContextLimit)
+	remainingPercent := 100 - usagePercent
+	if remainingPercent < 0 {
+		remainingPercent = 0 // Don't show negative percentages
+	}
+	return remainingPercent
+}
+
+// weeklyWidget shows weekly or daily usage, whichever is more restrictive. The daily
+// variant is a widget named "daily", styled like weekly unless the theme sets it.
+type weeklyWidget struct{}
+
+// Collect picks the period and adds {icon}, {pct} and {period}
+func (weeklyWidget) Collect(s *StatusLine, ctx *RenderContext, opts WidgetOptions) (TemplateFields, bool) {
+	weeklyTokensUsed := usage.WeeklyTokensUsed(ctx.CCUsage, ctx.Calculated)
+	if weeklyTokensUsed == 0 && ctx.DailyTokens == 0 {
+		return nil, false
+	}
+
+	dailyPercent := usage.DailyUsagePercentage(ctx.DailyTokens)
+	weeklyPercent := usage.WeeklyUsagePercentage(weeklyTokensUsed)
+	fields := s.Fields(ctx)
+
+	// Show the more restrictive limit (higher percentage)
+	if weeklyPercent > dailyPercent && weeklyPercent > 0 {
+		return fields.With(TemplateFields{"icon": s.Icon("weekly"), "pct": weeklyPercent, "period": "weekly"}), true
+	} else if dailyPercent > 0 {
+		return fields.With(TemplateFields{"icon": s.Icon("daily"), "pct": dailyPercent, "period": "daily"}), true
+	}
+	return nil, false
+}
+
+// Render adds the widget under its period's name
+func (weeklyWidget) Render(s *StatusLine, fields TemplateFields, opts WidgetOptions, style WidgetStyle) {
+	period, _ := fields.Get("period")
+	name, _ := period.(string)
+	pct, _ := fields.Get("pct")
+	percent, _ := pct.(int)
+	color, bgColor := s.Theme.Style(name, style).Colors(percent)
+	s.AddFormattedWidget(name, opts, "{icon} {pct}%", "{pct}%", fields, color, bgColor)
+}
+
+// DefaultStyle has no colors of its own; themes define "weekly"
+func (weeklyWidget) DefaultStyle() WidgetStyle {
+	return WidgetStyle{}
+}
+
+// sessionCost returns the session cost; ok is false when there is nothing to price
+func (s *StatusLine) sessionCost(ctx *RenderContext) (float64, bool) {
+	if reported, ok := ctx.Input.ReportedCost(); ok {
+		// Claude Code's own figure is authoritative
+		return reported, true
+	} else if len(ctx.Transcript.Models) > 0 {
+		// Per-request pricing including cache and long-context rates
+		return s.pricing().SessionCost(ctx.Transcript.Models), true
+	} else if ctx.SessionInputTokens > 0 || ctx.SessionOutputTokens > 0 {
+		return ctx.Model.Pricing.Cost(usage.TokenUsage{InputTokens: ctx.SessionInputTokens, OutputTokens: ctx.SessionOutputTokens}, false), true
+	}
+	return 0, false
+}
+
+// messagesWidget shows the message count against the "limit" option
+type messagesWidget struct{}
+
+// Collect adds {icon}, {used} and {limit}
+func (messagesWidget) Collect(s *StatusLine, ctx *RenderContext, opts WidgetOptions) (TemplateFields, bool) {
+	messageCount := usage.MessageCount(ctx.CCUsage, ctx.Calculated)
+	if messageCount == 0 {
+		return nil, false
+	}
+	return s.Fields(ctx).With(TemplateFields{
+		"icon":  s.Icon("messages"),
+		"used":  messageCount,
+		"limit": opts.Int("limit", usage.MessagesPerWindow),
+	}), true
+}
+
+// Render adds the formatted message count
+func (messagesWidget) Render(s *StatusLine, fields TemplateFields, opts WidgetOptions, style WidgetStyle) {
+	s.AddFormattedWidget("messages", opts, "{icon} {used}/{limit}", "{used}", fields, style.Fg, style.Bg)
+}
+
+// DefaultStyle has no colors of its own; themes define "messages"
+func (messagesWidget) DefaultStyle() WidgetStyle {
+	return WidgetStyle{}
+}
+
+// getNextReset returns the time to the most relevant reset and its type, 5hr or weekly
+func getNextReset(env schema.Env) (string, string) {
+	timeToReset, resetType := source.TimeToReset(env)
+
+	// Show whichever reset is sooner or more relevant
+	if resetType == "5hr" && timeToReset != "0m" {
+		return timeToReset, resetType
+	}
+	// Show weekly if 5hr window has expired or is unknown
+	return usage.TimeToWeeklyReset()
+}
+
+// customWidget shows text that comes entirely from its "format" option,
+// colored by the optional "fg" and "bg" color specs
+type customWidget struct{}
+
+// Collect requires a format and returns the shared fields
+func (customWidget) Collect(s *StatusLine, ctx *RenderContext, opts WidgetOptions) (TemplateFields, bool) {
+	if opts.String("format", "") == "" {
+		debug.Log("Custom widget has no format, skipping")
+		return nil, false
+	}
+	return s.Fields(ctx), true
+}
+
+// Render adds the formatted widget, letting "fg" and "bg" override the theme
+func (customWidget) Render(s *StatusLine, fields TemplateFields, opts WidgetOptions, style WidgetStyle) {
+	color := resolveColor(opts.String("fg", ""), false, style.Fg)
+	bgColor := resolveColor(opts.String("bg", ""), true, style.Bg)
+	s.AddFormattedWidget("custom", opts, opts.String("format", ""), "", fields, color, bgColor)
+}
+
+// DefaultStyle falls back to the tokens colors
+func (customWidget) DefaultStyle() WidgetStyle {
+	return WidgetStyle{Fallback: "tokens"}
+}
+
+// resolveColor parses an optional color spec from widget options or command output, keeping def when unset or invalid
+func resolveColor(spec string, background bool, def string) string {
+	if spec == "" {
+		return def
+	}
+	color, err := ParseColor(spec, background)
+	if err != nil {
+		debug.Log("Ignoring widget color: %v", err)
+		return def
+	}
+	return color
+}
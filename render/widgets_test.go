@@ -0,0 +1,82 @@
+package render
+
+import (
+	"testing"
+)
+
+// TestGitWidgetStatus tests the per-state summary in the git widget
+func TestGitWidgetStatus(t *testing.T) {
+	dirty := TemplateFields{
+		"icon":       GitBranch,
+		"branch":     "main",
+		"changes":    6,
+		"staged":     2,
+		"modified":   0,
+		"untracked":  3,
+		"conflicted": 1,
+		"stashed":    1,
+		"ahead":      2,
+		"behind":     0,
+	}
+	widget := widgetProviders["git"]
+
+	tests := []struct {
+		name   string
+		theme  Theme
+		fields TemplateFields
+		opts   WidgetOptions
+		want   string
+	}{
+		{
+			name:   "colored parts",
+			theme:  themes["minimal"],
+			fields: dirty,
+			want: GitBranch + " main " + ColorBrightCyan + "⇡2" + ColorBrightYellow + " " +
+				ColorBrightRed + "=1" + ColorBrightYellow + " " +
+				ColorBrightGreen + "+2" + ColorBrightYellow + " " + ColorBrightBlack + "?3" + ColorBrightYellow + " " +
+				ColorBrightMagenta + "≡1" + ColorBrightYellow,
+		},
+		{
+			name:   "theme without part styles",
+			theme:  Theme{Styles: map[string]WidgetStyle{"git": {Fg: ColorYellow}}},
+			fields: dirty,
+			want:   GitBranch + " main ⇡2 =1 +2 ?3 ≡1",
+		},
+		{
+			name:   "custom format",
+			theme:  themes["minimal"],
+			fields: dirty,
+			opts:   WidgetOptions{"format": "{branch} {staged}/{modified}/{untracked}"},
+			want:   "main 2/0/3",
+		},
+		{
+			name:   "rebase in progress",
+			theme:  themes["minimal"],
+			fields: TemplateFields{"icon": GitBranch, "branch": "feature", "operation": "REBASE", "step": 3, "steps": 7},
+			want:   GitBranch + " feature " + ColorBrightRed + "REBASE 3/7" + ColorBrightYellow,
+		},
+		{
+			name:   "merge in progress",
+			fields: TemplateFields{"icon": GitBranch, "branch": "main", "operation": "MERGING", "step": 0, "conflicted": 2},
+			want:   GitBranch + " main MERGING =2",
+		},
+		{
+			name:   "no upstream",
+			fields: TemplateFields{"icon": GitBranch, "branch": "main", "no_upstream": true},
+			want:   GitBranch + " main ⊘",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &StatusLine{Theme: tt.theme}
+			widget.Render(s, tt.fields, tt.opts, tt.theme.Style("git", widget.DefaultStyle()))
+			if len(s.Widgets) != 1 {
+				t.Fatalf("got %d widgets, want 1", len(s.Widgets))
+			}
+			if got := s.Widgets[0].Content; got != tt.want {
+				t.Errorf("content = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
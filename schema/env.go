@@ -0,0 +1,46 @@
+package schema
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// Env is the environment Claude Code ran the status line command with, as KEY=VALUE
+// pairs. A daemon renders for many clients, so lookups and the processes a render
+// starts use the client's Env rather than the daemon's own. A nil Env is this
+// process's environment.
+type Env []string
+
+// Getenv returns the value of key, or "" when it is unset
+func (e Env) Getenv(key string) string {
+	if e == nil {
+		return os.Getenv(key)
+	}
+	// Later entries win, as they do for exec
+	for i := len(e) - 1; i >= 0; i-- {
+		if k, value, ok := strings.Cut(e[i], "="); ok && k == key {
+			return value
+		}
+	}
+	return ""
+}
+
+// HomeDir returns the user's home directory, like os.UserHomeDir
+func (e Env) HomeDir() (string, error) {
+	if e == nil {
+		return os.UserHomeDir()
+	}
+	key := "HOME"
+	switch runtime.GOOS {
+	case "windows":
+		key = "USERPROFILE"
+	case "plan9":
+		key = "home"
+	}
+	if dir := e.Getenv(key); dir != "" {
+		return dir, nil
+	}
+	return "", errors.New("$" + key + " is not defined")
+}
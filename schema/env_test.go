@@ -0,0 +1,26 @@
+package schema
+
+import (
+	"testing"
+)
+
+// TestEnvGetenv tests lookups in a daemon client's environment list
+func TestEnvGetenv(t *testing.T) {
+	env := Env{"A=1", "B=x=y", "BROKEN", "A=2", "HOME=/home/client"}
+
+	if got := env.Getenv("A"); got != "2" {
+		t.Errorf("Getenv(A) = %q, want the last value 2", got)
+	}
+	if got := env.Getenv("B"); got != "x=y" {
+		t.Errorf("Getenv(B) = %q, want x=y", got)
+	}
+	if got := env.Getenv("BROKEN"); got != "" {
+		t.Errorf("Getenv(BROKEN) = %q, want empty", got)
+	}
+	if got, err := env.HomeDir(); got != "/home/client" || err != nil {
+		t.Errorf("HomeDir() = %q, %v, want /home/client", got, err)
+	}
+	if _, err := (Env{}).HomeDir(); err == nil {
+		t.Error("HomeDir() without HOME succeeded, want error")
+	}
+}
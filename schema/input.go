@@ -0,0 +1,145 @@
+// Package schema defines the JSON document Claude Code sends to status line
+// commands on stdin, with accessors that smooth over fields that moved between versions.
+package schema
+
+import (
+	"encoding/json"
+)
+
+// ModelInfo represents the model information from Claude Code
+type ModelInfo struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"display_name"`
+}
+
+// WorkspaceInfo represents the workspace information from Claude Code
+type WorkspaceInfo struct {
+	CurrentDir string `json:"current_dir"`
+	ProjectDir string `json:"project_dir"`
+}
+
+// UsageInfo represents token usage information
+type UsageInfo struct {
+	InputTokens  int `json:"inputTokens"`
+	OutputTokens int `json:"outputTokens"`
+	TotalTokens  int `json:"totalTokens"`
+}
+
+// ContextUsage represents context usage information
+type ContextUsage struct {
+	Characters int `json:"characters"`
+	Tokens     int `json:"tokens"`
+}
+
+// CostData represents cost information
+type CostData struct {
+	SessionCost float64 `json:"sessionCost"`
+	DailyCost   float64 `json:"dailyCost"`
+}
+
+// SessionCostInfo represents the cost object Claude Code sends for the session
+type SessionCostInfo struct {
+	TotalCostUSD       float64 `json:"total_cost_usd"`
+	TotalDurationMs    int64   `json:"total_duration_ms"`
+	TotalAPIDurationMs int64   `json:"total_api_duration_ms"`
+	TotalLinesAdded    int     `json:"total_lines_added"`
+	TotalLinesRemoved  int     `json:"total_lines_removed"`
+}
+
+// StatusLineInput represents the JSON input structure from Claude Code
+type StatusLineInput struct {
+	Model              ModelInfo        `json:"model"`
+	Workspace          WorkspaceInfo    `json:"workspace"`
+	WorkspaceDirectory string           `json:"workspaceDirectory"` // Alternative field
+	Usage              *UsageInfo       `json:"usage,omitempty"`
+	InputTokens        int              `json:"inputTokens,omitempty"`
+	OutputTokens       int              `json:"outputTokens,omitempty"`
+	TotalTokens        int              `json:"totalTokens,omitempty"`
+	ContextUsage       *ContextUsage    `json:"contextUsage,omitempty"`
+	Context            *ContextUsage    `json:"context,omitempty"`
+	CostData           *CostData        `json:"costData,omitempty"`
+	SessionCost        float64          `json:"sessionCost,omitempty"`
+	DailyCost          float64          `json:"dailyCost,omitempty"`
+	SessionID          string           `json:"session_id,omitempty"`
+	TranscriptPath     string           `json:"transcript_path,omitempty"`
+	Cost               *SessionCostInfo `json:"cost,omitempty"`
+	Exceeds200kTokens  bool             `json:"exceeds_200k_tokens,omitempty"`
+}
+
+// InputTokenCount returns the input tokens from the usage block, else the top-level field
+func (in StatusLineInput) InputTokenCount() int {
+	if in.Usage != nil && in.Usage.InputTokens > 0 {
+		return in.Usage.InputTokens
+	}
+	return in.InputTokens
+}
+
+// OutputTokenCount returns the output tokens from the usage block, else the top-level field
+func (in StatusLineInput) OutputTokenCount() int {
+	if in.Usage != nil && in.Usage.OutputTokens > 0 {
+		return in.Usage.OutputTokens
+	}
+	return in.OutputTokens
+}
+
+// TotalTokenCount returns the total tokens from the usage block, else the top-level field
+func (in StatusLineInput) TotalTokenCount() int {
+	if in.Usage != nil && in.Usage.TotalTokens > 0 {
+		return in.Usage.TotalTokens
+	}
+	return in.TotalTokens
+}
+
+// ContextTokenCount returns the current context size in tokens, or 0 if not reported
+func (in StatusLineInput) ContextTokenCount() int {
+	if in.ContextUsage != nil && in.ContextUsage.Tokens > 0 {
+		return in.ContextUsage.Tokens
+	}
+	if in.Context != nil && in.Context.Tokens > 0 {
+		return in.Context.Tokens
+	}
+	return 0
+}
+
+// ContextCharacterCount returns the current context size in characters, or 0 if not reported
+func (in StatusLineInput) ContextCharacterCount() int {
+	if in.ContextUsage != nil && in.ContextUsage.Characters > 0 {
+		return in.ContextUsage.Characters
+	}
+	if in.Context != nil && in.Context.Characters > 0 {
+		return in.Context.Characters
+	}
+	return 0
+}
+
+// ReportedCost returns the cost reported by Claude Code, if any
+func (in StatusLineInput) ReportedCost() (float64, bool) {
+	if in.Cost != nil && in.Cost.TotalCostUSD > 0 {
+		return in.Cost.TotalCostUSD, true
+	}
+	if in.CostData != nil && in.CostData.SessionCost > 0 {
+		return in.CostData.SessionCost, true
+	}
+	if in.SessionCost > 0 {
+		return in.SessionCost, true
+	}
+	return 0, false
+}
+
+// WorkspacePath returns the current directory, falling back to "~"
+func (in StatusLineInput) WorkspacePath() string {
+	if in.Workspace.CurrentDir != "" {
+		return in.Workspace.CurrentDir
+	}
+	if in.WorkspaceDirectory != "" {
+		return in.WorkspaceDirectory
+	}
+	return "~"
+}
+
+// Parse decodes the status line JSON sent by Claude Code
+func Parse(data []byte) (StatusLineInput, error) {
+	var in StatusLineInput
+	err := json.Unmarshal(data, &in)
+	return in, err
+}
@@ -0,0 +1,43 @@
+package schema
+
+import (
+	"testing"
+)
+
+// TestReportedCost tests preferring the cost reported by Claude Code
+func TestReportedCost(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  StatusLineInput
+		want   float64
+		wantOk bool
+	}{
+		{
+			name:   "cost object",
+			input:  StatusLineInput{Cost: &SessionCostInfo{TotalCostUSD: 1.25}, SessionCost: 9},
+			want:   1.25,
+			wantOk: true,
+		},
+		{
+			name:   "legacy cost data",
+			input:  StatusLineInput{CostData: &CostData{SessionCost: 0.5}},
+			want:   0.5,
+			wantOk: true,
+		},
+		{
+			name:   "zero cost falls through",
+			input:  StatusLineInput{Cost: &SessionCostInfo{TotalDurationMs: 1000}},
+			want:   0,
+			wantOk: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.input.ReportedCost()
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("ReportedCost() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
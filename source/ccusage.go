@@ -0,0 +1,252 @@
+package source
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mrdavidaylward/ccstatus/internal/cache"
+	"github.com/mrdavidaylward/ccstatus/internal/debug"
+	"github.com/mrdavidaylward/ccstatus/schema"
+	"github.com/mrdavidaylward/ccstatus/usage"
+)
+
+// ccusageCacheTTL is how long ccusage results are reused across renders
+const ccusageCacheTTL = 2 * time.Second
+
+var (
+	regexCache    = make(map[string]*regexp.Regexp)
+	regexCacheMux sync.RWMutex
+)
+
+// CCUsage returns usage.CCUsageData from env's disk cache if fresh, keyed by session
+func CCUsage(sessionID string, env schema.Env) usage.CCUsageData {
+	var data usage.CCUsageData
+	key := cache.Key("ccusage", sessionID)
+	if cache.Get(env, "ccusage", key, ccusageCacheTTL, &data) {
+		return data
+	}
+
+	debug.Log("Cache miss or expired, fetching fresh ccusage data")
+	data, ok := usage.NativeUsageData(env)
+	if !ok {
+		data = getCCUsageData(sessionID, env)
+	}
+
+	if err := cache.Put(env, "ccusage", key, data); err != nil {
+		debug.Log("Failed to cache ccusage data: %v", err)
+	}
+	return data
+}
+
+func getCCUsageData(sessionID string, env schema.Env) usage.CCUsageData {
+	var data usage.CCUsageData
+
+	if _, err := lookPath(env, "ccusage"); err != nil {
+		debug.Log("ccusage command not found: %v", err)
+		return data
+	}
+
+	// Get current active 5-hour block data (most accurate for rate limits)
+	cmd := command(env, "ccusage", "blocks", "--active", "--json")
+	if output, err := cmd.Output(); err == nil {
+		outputStr := string(output)
+		// Parse current active block data only
+		data.SessionTokens = extractTokenCount(outputStr, `"totalTokens"\s*:\s*(\d+)`)
+		data.InputTokens = extractTokenCount(outputStr, `"inputTokens"\s*:\s*(\d+)`)
+		data.OutputTokens = extractTokenCount(outputStr, `"outputTokens"\s*:\s*(\d+)`)
+		data.Messages = extractTokenCount(outputStr, `"entries"\s*:\s*(\d+)`)
+		debug.Log("Fetched active block data: %d tokens, %d messages", data.SessionTokens, data.Messages)
+	} else {
+		debug.Log("Failed to get active block data: %v", err)
+	}
+
+	// Get session-specific data if we have a session ID
+	if sessionID == "" {
+		sessionID = getCurrentSessionID(env)
+	}
+	if sessionID != "" {
+		data.SessionID = sessionID
+		cmd := command(env, "ccusage", "session", sessionID, "--json")
+		if output, err := cmd.Output(); err == nil {
+			outputStr := string(output)
+			// Override with session-specific data if available
+			if sessionTokens := extractTokenCount(outputStr, `"tokens"\s*:\s*(\d+)|"totalTokens"\s*:\s*(\d+)`); sessionTokens > 0 {
+				data.SessionTokens = sessionTokens
+			}
+			if inputTokens := extractTokenCount(outputStr, `"inputTokens"\s*:\s*(\d+)`); inputTokens > 0 {
+				data.InputTokens = inputTokens
+			}
+			if outputTokens := extractTokenCount(outputStr, `"outputTokens"\s*:\s*(\d+)`); outputTokens > 0 {
+				data.OutputTokens = outputTokens
+			}
+			if messages := extractTokenCount(outputStr, `"messages"\s*:\s*(\d+)|"messageCount"\s*:\s*(\d+)`); messages > 0 {
+				data.Messages = messages
+			}
+		}
+	}
+
+	// Get overall daily stats for daily totals
+	cmd = command(env, "ccusage", "stats", "--json")
+	output, err := cmd.Output()
+	if err != nil {
+		// Fallback to plain text output
+		cmd = command(env, "ccusage", "stats")
+		output, err = cmd.Output()
+		if err != nil {
+			return data
+		}
+	}
+
+	outputStr := string(output)
+	data.DailyTokens = extractTokenCount(outputStr, `"totalTokens"\s*:\s*(\d+)|total.*tokens.*:\s*(\d+)`)
+	data.WeeklyTokens = extractTokenCount(outputStr, `"weeklyTokens"\s*:\s*(\d+)|weekly.*tokens.*:\s*(\d+)`)
+
+	// If we still don't have session data, try to extract from general stats
+	if data.SessionTokens == 0 {
+		data.SessionTokens = extractTokenCount(outputStr, `"sessionTokens"\s*:\s*(\d+)|session.*tokens.*:\s*(\d+)`)
+	}
+	if data.InputTokens == 0 {
+		data.InputTokens = extractTokenCount(outputStr, `"inputTokens"\s*:\s*(\d+)|input.*tokens.*:\s*(\d+)`)
+	}
+	if data.OutputTokens == 0 {
+		data.OutputTokens = extractTokenCount(outputStr, `"outputTokens"\s*:\s*(\d+)|output.*tokens.*:\s*(\d+)`)
+	}
+	if data.Messages == 0 {
+		data.Messages = extractTokenCount(outputStr, `"messages"\s*:\s*(\d+)|message.*count.*:\s*(\d+)`)
+	}
+
+	return data
+}
+
+// getCurrentSessionID attempts to get the current Claude Code session ID
+func getCurrentSessionID(env schema.Env) string {
+	// Try multiple methods to get session ID
+
+	// Method 1: Check environment variable
+	if sessionID := env.Getenv("CLAUDE_SESSION_ID"); sessionID != "" {
+		return sessionID
+	}
+
+	// Method 2: Check for session file in ~/.claude/
+	homeDir, err := env.HomeDir()
+	if err == nil {
+		sessionFile := filepath.Join(homeDir, ".claude", "current_session")
+		if content, err := os.ReadFile(sessionFile); err == nil {
+			return strings.TrimSpace(string(content))
+		}
+	}
+
+	// Method 3: Try to extract from Claude Code process info
+	if sessionID := extractSessionFromProcess(env); sessionID != "" {
+		return sessionID
+	}
+
+	return ""
+}
+
+// extractSessionFromProcess tries to extract session ID from running Claude Code processes
+func extractSessionFromProcess(env schema.Env) string {
+	cmd := command(env, "ps", "aux")
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+
+	lines := strings.Split(string(output), "\n")
+	for _, line := range lines {
+		if strings.Contains(line, "claude") && strings.Contains(line, "session") {
+			// Try to extract session ID from command line
+			re := regexp.MustCompile(`session[=:]([a-zA-Z0-9-]+)`)
+			if matches := re.FindStringSubmatch(line); len(matches) > 1 {
+				return matches[1]
+			}
+		}
+	}
+
+	return ""
+}
+
+// ScriptUsage runs ~/.claude/calculate-usage.sh, if present, with env
+func ScriptUsage(env schema.Env) usage.CalculatedUsage {
+	var calculated usage.CalculatedUsage
+
+	homeDir, err := env.HomeDir()
+	if err != nil {
+		debug.Log("Failed to get home directory: %v", err)
+		return calculated
+	}
+
+	scriptPath := filepath.Join(homeDir, ".claude", "calculate-usage.sh")
+	if _, err := os.Stat(scriptPath); os.IsNotExist(err) {
+		debug.Log("calculate-usage.sh not found at %s", scriptPath)
+		return calculated
+	}
+
+	cmd := command(env, scriptPath)
+	output, err := cmd.Output()
+	if err != nil {
+		debug.Log("Failed to execute calculate-usage.sh: %v", err)
+		return calculated
+	}
+
+	parts := strings.Fields(string(output))
+	if len(parts) >= 5 {
+		if val, err := strconv.Atoi(parts[0]); err == nil {
+			calculated.SessionTokens = val
+		}
+		if val, err := strconv.Atoi(parts[1]); err == nil {
+			calculated.DailyTokens = val
+		}
+		if val, err := strconv.Atoi(parts[2]); err == nil {
+			calculated.Messages = val
+		}
+		if val, err := strconv.Atoi(parts[3]); err == nil {
+			calculated.InputTokens = val
+		}
+		if val, err := strconv.Atoi(parts[4]); err == nil {
+			calculated.OutputTokens = val
+		}
+	} else if len(parts) >= 3 {
+		if val, err := strconv.Atoi(parts[0]); err == nil {
+			calculated.SessionTokens = val
+		}
+		if val, err := strconv.Atoi(parts[1]); err == nil {
+			calculated.DailyTokens = val
+		}
+		if val, err := strconv.Atoi(parts[2]); err == nil {
+			calculated.Messages = val
+		}
+	}
+
+	return calculated
+}
+
+func extractTokenCount(text, pattern string) int {
+	// Use cached regex compilation for performance
+	regexCacheMux.RLock()
+	re, exists := regexCache[pattern]
+	regexCacheMux.RUnlock()
+
+	if !exists {
+		regexCacheMux.Lock()
+		re = regexp.MustCompile(pattern)
+		regexCache[pattern] = re
+		regexCacheMux.Unlock()
+		debug.Log("Compiled and cached regex pattern: %s", pattern)
+	}
+
+	matches := re.FindStringSubmatch(text)
+	for i := 1; i < len(matches); i++ {
+		if matches[i] != "" {
+			if count, err := strconv.Atoi(matches[i]); err == nil {
+				return count
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,52 @@
+package source
+
+import (
+	"testing"
+)
+
+// TestExtractTokenCount tests token extraction from text
+func TestExtractTokenCount(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		pattern string
+		want    int
+	}{
+		{
+			name:    "json number",
+			text:    `{"totalTokens": 12345}`,
+			pattern: `"totalTokens"\s*:\s*(\d+)`,
+			want:    12345,
+		},
+		{
+			name:    "plain text",
+			text:    "total tokens: 54321",
+			pattern: `total.*tokens.*:\s*(\d+)`,
+			want:    54321,
+		},
+		{
+			name:    "no match",
+			text:    "no numbers here",
+			pattern: `tokens:\s*(\d+)`,
+			want:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extractTokenCount(tt.text, tt.pattern)
+			if got != tt.want {
+				t.Errorf("extractTokenCount() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func BenchmarkExtractTokenCount(b *testing.B) {
+	text := `{"totalTokens": 12345, "inputTokens": 8000}`
+	pattern := `"totalTokens"\s*:\s*(\d+)`
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		extractTokenCount(text, pattern)
+	}
+}
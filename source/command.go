@@ -0,0 +1,143 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mrdavidaylward/ccstatus/internal/cache"
+	"github.com/mrdavidaylward/ccstatus/internal/debug"
+	"github.com/mrdavidaylward/ccstatus/schema"
+)
+
+// Command widgets run a user-supplied shell command on each render. The command
+// receives the schema.StatusLineInput as JSON on stdin and prints either plain text or
+// a JSON object such as
+//
+//	{"text": "on-call", "compact": "oc", "fg": "red", "bg": "#cc241d", "priority": 80}
+//
+// Failures, timeouts and empty output hide the widget rather than the status line.
+
+// Command widget defaults, overridable per widget with the timeout and cache options
+const (
+	DefaultCommandTimeout  = 200 * time.Millisecond
+	DefaultCommandCacheTTL = 5 * time.Second
+	commandWaitDelay       = 50 * time.Millisecond // Grace period for children still holding stdout
+)
+
+// CommandOutput is the reply a command widget prints on stdout
+type CommandOutput struct {
+	Text     string `json:"text"`
+	Compact  string `json:"compact,omitempty"`  // Shorter form for narrow terminals
+	Fg       string `json:"fg,omitempty"`       // Color spec, as in custom themes
+	Bg       string `json:"bg,omitempty"`       // Color spec, as in custom themes
+	Priority int    `json:"priority,omitempty"` // Layout priority unless the config sets one
+}
+
+// RunCommand runs command through the shell with the input JSON on stdin and env as its environment
+func RunCommand(command string, input schema.StatusLineInput, timeout time.Duration, env schema.Env) (CommandOutput, error) {
+	payload, err := json.Marshal(input)
+	if err != nil {
+		return CommandOutput{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := commandContext(ctx, env, "sh", "-c", command)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.WaitDelay = commandWaitDelay
+	if dir := input.WorkspacePath(); dir != "" {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			cmd.Dir = dir
+		}
+	}
+
+	output, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return CommandOutput{}, fmt.Errorf("timed out after %v", timeout)
+	}
+	if err != nil {
+		return CommandOutput{}, err
+	}
+	return parseCommandOutput(output)
+}
+
+// parseCommandOutput decodes a JSON reply, or takes the first line of plain text output
+func parseCommandOutput(output []byte) (CommandOutput, error) {
+	text := strings.TrimSpace(string(output))
+	if strings.HasPrefix(text, "{") {
+		var result CommandOutput
+		if err := json.Unmarshal([]byte(text), &result); err != nil {
+			return CommandOutput{}, fmt.Errorf("invalid JSON output: %w", err)
+		}
+		result.Text = strings.TrimSpace(result.Text)
+		result.Compact = strings.TrimSpace(result.Compact)
+		return result, nil
+	}
+
+	if line, _, found := strings.Cut(text, "\n"); found {
+		text = strings.TrimSpace(line)
+	}
+	return CommandOutput{Text: text}, nil
+}
+
+// CachedCommand runs a widget command, reusing its result for ttl.
+// Failures are cached as empty output too, so a hanging command costs one timeout per ttl.
+func CachedCommand(command string, input schema.StatusLineInput, timeout, ttl time.Duration, env schema.Env) CommandOutput {
+	key := cache.Key(command, input.SessionID, input.WorkspacePath())
+
+	var result CommandOutput
+	if ttl > 0 && cache.Get(env, "command", key, ttl, &result) {
+		return result
+	}
+
+	result, err := RunCommand(command, input, timeout, env)
+	if err != nil {
+		debug.Log("Command widget %q failed: %v", command, err)
+	}
+	if ttl > 0 {
+		if err := cache.Put(env, "command", key, result); err != nil {
+			debug.Log("Failed to cache command output: %v", err)
+		}
+	}
+	return result
+}
+
+// commandContext prepares a process the way exec.CommandContext does, but running with
+// env and found on env's PATH; a daemon's own environment is not its client's
+func commandContext(ctx context.Context, env schema.Env, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	if env != nil {
+		cmd.Path, cmd.Err = lookPath(env, name)
+		cmd.Env = env
+	}
+	return cmd
+}
+
+// command is commandContext without a deadline
+func command(env schema.Env, name string, args ...string) *exec.Cmd {
+	return commandContext(context.Background(), env, name, args...)
+}
+
+// lookPath is exec.LookPath over env's PATH
+func lookPath(env schema.Env, name string) (string, error) {
+	if env == nil || strings.ContainsAny(name, `/\`) {
+		return exec.LookPath(name)
+	}
+	for _, dir := range filepath.SplitList(env.Getenv("PATH")) {
+		if dir == "" {
+			continue
+		}
+		if path, err := exec.LookPath(filepath.Join(dir, name)); err == nil {
+			return path, nil
+		}
+	}
+	return "", &exec.Error{Name: name, Err: exec.ErrNotFound}
+}
@@ -0,0 +1,78 @@
+package source
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mrdavidaylward/ccstatus/schema"
+)
+
+// TestParseCommandOutput tests JSON and plain text replies
+func TestParseCommandOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    CommandOutput
+		wantErr bool
+	}{
+		{name: "plain text", output: "on-call\n", want: CommandOutput{Text: "on-call"}},
+		{name: "first line only", output: "PROJ-123\nsecond line\n", want: CommandOutput{Text: "PROJ-123"}},
+		{name: "empty", output: "", want: CommandOutput{}},
+		{
+			name:   "json",
+			output: `{"text": " build ok ", "compact": "ok", "fg": "green", "bg": "#282828", "priority": 80}`,
+			want:   CommandOutput{Text: "build ok", Compact: "ok", Fg: "green", Bg: "#282828", Priority: 80},
+		},
+		{name: "invalid json", output: `{"text": `, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCommandOutput([]byte(tt.output))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCommandOutput() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseCommandOutput() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRunWidgetCommand tests the stdin payload, working directory, failures and timeouts
+func TestRunWidgetCommand(t *testing.T) {
+	dir := t.TempDir()
+	input := schema.StatusLineInput{SessionID: "abc123"}
+	input.Workspace.CurrentDir = dir
+
+	result, err := RunCommand(`grep -o '"session_id":"[^"]*"' | cut -d'"' -f4`, input, time.Second, nil)
+	if err != nil {
+		t.Fatalf("RunCommand() error: %v", err)
+	}
+	if result.Text != "abc123" {
+		t.Errorf("command read %q from stdin, want %q", result.Text, "abc123")
+	}
+
+	result, err = RunCommand("pwd", input, time.Second, nil)
+	if err != nil {
+		t.Fatalf("RunCommand() error: %v", err)
+	}
+	if want, _ := filepath.EvalSymlinks(dir); result.Text != want && result.Text != dir {
+		t.Errorf("command ran in %q, want %q", result.Text, dir)
+	}
+
+	if _, err := RunCommand("exit 3", input, time.Second, nil); err == nil {
+		t.Error("RunCommand() expected error for failing command")
+	}
+
+	start := time.Now()
+	_, err = RunCommand("sleep 5", input, 50*time.Millisecond, nil)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("RunCommand() error = %v, want timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("timed out command took %v", elapsed)
+	}
+}
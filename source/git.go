@@ -0,0 +1,551 @@
+// Package source collects the data widgets display from outside the input JSON:
+// git, ccusage, the calculate-usage script, system facts and external commands.
+package source
+
+import (
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mrdavidaylward/ccstatus/internal/cache"
+	"github.com/mrdavidaylward/ccstatus/schema"
+)
+
+// gitCacheTTL is how long the working tree status is reused while HEAD, the index
+// and refs are unchanged; edits to tracked files only show up once it expires
+const gitCacheTTL = 2 * time.Second
+
+// GitInfo describes the repository state shown by the git widget
+type GitInfo struct {
+	GitStatus
+	Branch    string // Branch name; for a detached HEAD, a tag or remote branch at HEAD or the abbreviated commit
+	Worktree  string // Linked worktree name; empty in the main checkout
+	Stashed   int    // Entries in the stash
+	Operation GitOperation
+}
+
+// GitOperation is a multi-step operation left in progress in the working tree
+type GitOperation struct {
+	Name  string // REBASE, AM, AM/REBASE, MERGING, CHERRY-PICKING, REVERTING or BISECTING; empty when idle
+	Step  int    // Current step of a rebase or am, 0 when not applicable
+	Total int    // Number of steps of a rebase or am
+}
+
+// GitStatus counts working tree entries by state and compares the branch with its
+// upstream. An entry with both staged and unstaged changes counts as staged and modified.
+type GitStatus struct {
+	Changes    int // Number of changed files, in any state
+	Staged     int // Changes in the index
+	Modified   int // Unstaged changes to tracked files
+	Untracked  int
+	Conflicted int // Unmerged paths
+
+	Upstream   string // Configured upstream such as origin/main
+	Ahead      int    // Commits on the branch but not its upstream
+	Behind     int    // Commits on the upstream but not the branch
+	NoUpstream bool   // On a branch that has no upstream configured
+}
+
+// gitRepo locates the directories git keeps a checkout's state in
+type gitRepo struct {
+	gitDir    string // Per-checkout state: HEAD, index, in-progress operations
+	commonDir string // State shared by all worktrees: refs, packed-refs, config
+}
+
+// worktree returns the linked worktree's name, or "" for the main checkout.
+// Linked worktrees keep their state in <commondir>/worktrees/<name>.
+func (r gitRepo) worktree() string {
+	if r.gitDir == r.commonDir {
+		return ""
+	}
+	if filepath.Base(filepath.Dir(r.gitDir)) != "worktrees" {
+		return ""
+	}
+	return filepath.Base(r.gitDir)
+}
+
+// reftable reports whether refs are kept in reftable files rather than loose files
+// and packed-refs. Such repositories leave a placeholder HEAD for older gits.
+func (r gitRepo) reftable() bool {
+	info, err := os.Stat(filepath.Join(r.commonDir, "reftable"))
+	return err == nil && info.IsDir()
+}
+
+// ReadGitInfo reads the branch and change count, running git with env; ok is false
+// outside a repository
+func ReadGitInfo(dir string, env schema.Env) (info GitInfo, ok bool) {
+	repo, ok := findGitDir(dir)
+	if !ok {
+		return GitInfo{}, false
+	}
+
+	branch, commit, ok := readHead(repo, dir, env)
+	if !ok {
+		return GitInfo{}, false
+	}
+	operation, rebasing := readGitOperation(repo.gitDir)
+	if branch == "" && rebasing != "" {
+		branch = rebasing // HEAD is detached while a rebase replays commits
+	} else if branch == "" {
+		branch = detachedName(repo, dir, commit, env)
+	}
+
+	return GitInfo{
+		GitStatus: getGitStatus(repo, dir, env, gitCacheTTL),
+		Branch:    branch,
+		Worktree:  repo.worktree(),
+		Stashed:   countStashes(repo, dir, env),
+		Operation: operation,
+	}, true
+}
+
+// findGitDir finds the repository containing startDir, searching up to and including /.
+// Linked worktrees and submodules have a .git file pointing at their git dir instead
+// of a .git directory, and worktrees share refs through the git dir's commondir file.
+func findGitDir(startDir string) (gitRepo, bool) {
+	dir := startDir
+	for {
+		if gitDir, ok := resolveDotGit(filepath.Join(dir, ".git")); ok {
+			return gitRepo{gitDir: gitDir, commonDir: resolveCommonDir(gitDir)}, true
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return gitRepo{}, false
+		}
+		dir = parent
+	}
+}
+
+// resolveDotGit returns the git dir a .git directory or "gitdir:" file refers to
+func resolveDotGit(dotGit string) (string, bool) {
+	info, err := os.Stat(dotGit)
+	if err != nil {
+		return "", false
+	}
+	if info.IsDir() {
+		return dotGit, true
+	}
+
+	content, err := os.ReadFile(dotGit)
+	if err != nil {
+		return "", false
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(content)), "gitdir:")
+	if !ok {
+		return "", false
+	}
+	gitDir = strings.TrimSpace(gitDir)
+	if !filepath.IsAbs(gitDir) {
+		// Relative paths are relative to the directory holding the .git file
+		gitDir = filepath.Join(filepath.Dir(dotGit), gitDir)
+	}
+	if info, err := os.Stat(gitDir); err != nil || !info.IsDir() {
+		return "", false
+	}
+	return filepath.Clean(gitDir), true
+}
+
+// resolveCommonDir follows a git dir's commondir file, returning gitDir itself when there is none
+func resolveCommonDir(gitDir string) string {
+	content, err := os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		return gitDir
+	}
+	commonDir := strings.TrimSpace(string(content))
+	if commonDir == "" {
+		return gitDir
+	}
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(gitDir, commonDir)
+	}
+	return filepath.Clean(commonDir)
+}
+
+// readGitOperation detects an operation in progress from the state files in the git dir,
+// checked in the same order as git's own prompt. branch is the branch being rebased, if any.
+func readGitOperation(gitDir string) (op GitOperation, branch string) {
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(gitDir, name))
+		return err == nil
+	}
+	readInt := func(name string) int {
+		content, err := os.ReadFile(filepath.Join(gitDir, name))
+		if err != nil {
+			return 0
+		}
+		n, _ := strconv.Atoi(strings.TrimSpace(string(content)))
+		return n
+	}
+	headName := func(stateDir string) string {
+		content, err := os.ReadFile(filepath.Join(gitDir, stateDir, "head-name"))
+		if err != nil {
+			return ""
+		}
+		return strings.TrimPrefix(strings.TrimSpace(string(content)), "refs/heads/")
+	}
+
+	switch {
+	case exists("rebase-merge"):
+		op = GitOperation{Name: "REBASE", Step: readInt("rebase-merge/msgnum"), Total: readInt("rebase-merge/end")}
+		branch = headName("rebase-merge")
+	case exists("rebase-apply"):
+		// git am and the apply backend of rebase share this directory
+		op = GitOperation{Name: "AM/REBASE", Step: readInt("rebase-apply/next"), Total: readInt("rebase-apply/last")}
+		if exists("rebase-apply/rebasing") {
+			op.Name = "REBASE"
+			branch = headName("rebase-apply")
+		} else if exists("rebase-apply/applying") {
+			op.Name = "AM"
+		}
+	case exists("MERGE_HEAD"):
+		op.Name = "MERGING"
+	case exists("CHERRY_PICK_HEAD"):
+		op.Name = "CHERRY-PICKING"
+	case exists("REVERT_HEAD"):
+		op.Name = "REVERTING"
+	case exists("BISECT_LOG"):
+		op.Name = "BISECTING"
+	}
+
+	if branch == "detached HEAD" {
+		branch = "" // Rebasing a detached HEAD
+	}
+	return op, branch
+}
+
+// RefreshGitStatus re-runs git status for dir unless the cached status is recent and
+// HEAD, the index, refs and config are unchanged since, so the next render finds it cached.
+// The daemon calls it for the workspaces it renders.
+func RefreshGitStatus(dir string, env schema.Env) {
+	if repo, ok := findGitDir(dir); ok {
+		getGitStatus(repo, dir, env, gitCacheTTL/2)
+	}
+}
+
+// cachedGitStatus is a git status with the stamp of the git state it was read from
+type cachedGitStatus struct {
+	Stamp  string
+	Status GitStatus
+}
+
+// getGitStatus runs git status in dir and counts entries by state, reusing a cached
+// status younger than maxAge whose git state is unchanged
+func getGitStatus(repo gitRepo, dir string, env schema.Env, maxAge time.Duration) GitStatus {
+	// Validate and clean the directory path to prevent directory traversal
+	dir = filepath.Clean(dir)
+	if !filepath.IsAbs(dir) {
+		return GitStatus{}
+	}
+
+	// Verify directory exists
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return GitStatus{}
+	}
+
+	var cached cachedGitStatus
+	key := cache.Key("status", dir)
+	stamp := gitStateStamp(repo)
+	if cache.Get(env, "git", key, maxAge, &cached) && cached.Stamp == stamp {
+		return cached.Status
+	}
+
+	cmd := command(env, "git", "status", "--porcelain=v2", "--branch")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return GitStatus{}
+	}
+
+	status := parseGitStatus(output)
+	cache.Put(env, "git", key, cachedGitStatus{Stamp: stamp, Status: status})
+	return status
+}
+
+// gitStateStamp fingerprints HEAD, the index, refs and the config naming upstreams.
+// git updates each by renaming a new file into place, which also changes the mtime of
+// the directory holding a ref, so the directories under refs (or reftable) cover every ref.
+func gitStateStamp(repo gitRepo) string {
+	var parts []string
+	add := func(path string) {
+		if info, err := os.Stat(path); err == nil {
+			parts = append(parts, path, strconv.FormatInt(info.ModTime().UnixNano(), 10), strconv.FormatInt(info.Size(), 10))
+		}
+	}
+	add(filepath.Join(repo.gitDir, "HEAD"))
+	add(filepath.Join(repo.gitDir, "index"))
+	add(filepath.Join(repo.commonDir, "packed-refs"))
+	add(filepath.Join(repo.commonDir, "config"))
+	for _, root := range []string{"refs", "reftable"} {
+		filepath.WalkDir(filepath.Join(repo.commonDir, root), func(path string, d fs.DirEntry, err error) error {
+			if err == nil && d.IsDir() {
+				add(path)
+			}
+			return nil
+		})
+	}
+	return cache.Key(parts...)
+}
+
+// parseGitStatus reads git status --porcelain=v2 --branch output. Ahead/behind
+// counts come from git comparing local refs, so they are as fresh as the last fetch.
+func parseGitStatus(output []byte) GitStatus {
+	var status GitStatus
+	onBranch := false
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.SplitN(line, " ", 3)
+		switch fields[0] {
+		case "#":
+			if len(fields) < 3 {
+				continue
+			}
+			switch fields[1] {
+			case "branch.head":
+				onBranch = fields[2] != "(detached)"
+			case "branch.upstream":
+				status.Upstream = fields[2]
+			case "branch.ab":
+				// +<ahead> -<behind>; missing when the upstream ref is gone
+				fmt.Sscanf(fields[2], "+%d -%d", &status.Ahead, &status.Behind)
+			}
+		case "1", "2":
+			// Ordinary and renamed/copied entries: XY is the index and worktree state
+			if len(fields) < 2 || len(fields[1]) != 2 {
+				continue
+			}
+			status.Changes++
+			if fields[1][0] != '.' {
+				status.Staged++
+			}
+			if fields[1][1] != '.' {
+				status.Modified++
+			}
+		case "u":
+			status.Changes++
+			status.Conflicted++
+		case "?":
+			status.Changes++
+			status.Untracked++
+		}
+	}
+	status.NoUpstream = onBranch && status.Upstream == ""
+	return status
+}
+
+// countStashes counts stash entries from the stash reflog, one line per entry
+func countStashes(repo gitRepo, dir string, env schema.Env) int {
+	if repo.reftable() {
+		// Reflogs live in the reftable too
+		output, err := gitOutput(dir, env, "rev-list", "--walk-reflogs", "--count", "refs/stash")
+		if err != nil {
+			return 0
+		}
+		n, _ := strconv.Atoi(output)
+		return n
+	}
+	content, err := os.ReadFile(filepath.Join(repo.commonDir, "logs", "refs", "stash"))
+	if err != nil {
+		return 0
+	}
+	return strings.Count(string(content), "\n")
+}
+
+// gitOutput runs git in dir and returns its trimmed output
+func gitOutput(dir string, env schema.Env, args ...string) (string, error) {
+	cmd := command(env, "git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	return strings.TrimSpace(string(output)), err
+}
+
+// readHead returns the branch HEAD points at, or the commit of a detached HEAD
+func readHead(repo gitRepo, dir string, env schema.Env) (branch, commit string, ok bool) {
+	if repo.reftable() {
+		if ref, err := gitOutput(dir, env, "symbolic-ref", "-q", "HEAD"); err == nil {
+			return strings.TrimPrefix(ref, "refs/heads/"), "", true
+		}
+		commit, err := gitOutput(dir, env, "rev-parse", "-q", "--verify", "HEAD")
+		return "", commit, err == nil && isObjectID(commit)
+	}
+
+	content, err := os.ReadFile(filepath.Join(repo.gitDir, "HEAD"))
+	if err != nil {
+		return "", "", false
+	}
+	head := strings.TrimSpace(string(content))
+	if ref, ok := strings.CutPrefix(head, "ref: "); ok {
+		return strings.TrimPrefix(ref, "refs/heads/"), "", true
+	}
+	return "", head, isObjectID(head)
+}
+
+// isObjectID reports whether s is a full SHA-1 or SHA-256 object ID
+func isObjectID(s string) bool {
+	if len(s) != 40 && len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// detachedName names a detached HEAD after a tag or remote branch pointing at commit,
+// falling back to the abbreviated commit. Tags win over remote branches.
+func detachedName(repo gitRepo, dir, commit string, env schema.Env) string {
+	var name string
+	key := cache.Key("ref", repo.commonDir, commit)
+	if cache.Get(env, "git", key, gitCacheTTL, &name) {
+		return name
+	}
+
+	var refs []string
+	if repo.reftable() {
+		refs = gitRefsAt(dir, env)
+	} else {
+		var complete bool
+		refs, complete = refsAt(repo.commonDir, commit)
+		if len(refs) == 0 && !complete {
+			refs = gitRefsAt(dir, env)
+		}
+	}
+
+	name = commit[:7]
+	sort.Strings(refs)
+	for _, prefix := range []string{"refs/tags/", "refs/remotes/"} {
+		if ref := firstRef(refs, prefix); ref != "" {
+			name = ref
+			break
+		}
+	}
+	cache.Put(env, "git", key, name)
+	return name
+}
+
+// firstRef returns the first of refs under prefix with the prefix removed, skipping
+// remote HEADs, which only repeat a remote branch
+func firstRef(refs []string, prefix string) string {
+	for _, ref := range refs {
+		if name, ok := strings.CutPrefix(ref, prefix); ok && !strings.HasSuffix(name, "/HEAD") {
+			return name
+		}
+	}
+	return ""
+}
+
+// gitRefsAt asks git for the tags and remote branches pointing at HEAD
+func gitRefsAt(dir string, env schema.Env) []string {
+	output, err := gitOutput(dir, env, "for-each-ref", "--points-at=HEAD", "--format=%(refname)", "refs/tags", "refs/remotes")
+	if err != nil || output == "" {
+		return nil
+	}
+	return strings.Split(output, "\n")
+}
+
+// refsAt finds the tags and remote branches pointing at commit in packed-refs and the
+// loose refs under commonDir. complete is false when a loose tag's object is packed,
+// so whether the tag points at commit could not be told without git.
+func refsAt(commonDir, commit string) (refs []string, complete bool) {
+	targets := readPackedRefs(commonDir)
+	complete = true
+	for _, dir := range []string{"refs/tags", "refs/remotes"} {
+		root := filepath.Join(commonDir, filepath.FromSlash(dir))
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil
+			}
+			id := strings.TrimSpace(string(content))
+			if !isObjectID(id) {
+				return nil // Symbolic refs such as origin/HEAD
+			}
+			if dir == "refs/tags" {
+				var ok bool
+				if id, ok = peelTag(commonDir, id); !ok {
+					complete = false
+				}
+			}
+			rel, _ := filepath.Rel(commonDir, path)
+			targets[filepath.ToSlash(rel)] = id // Loose refs override packed ones
+			return nil
+		})
+	}
+
+	for ref, id := range targets {
+		if id == commit {
+			refs = append(refs, ref)
+		}
+	}
+	return refs, complete
+}
+
+// readPackedRefs maps each ref in packed-refs to its object, or for annotated tags to
+// the commit on the "^" line that follows them
+func readPackedRefs(commonDir string) map[string]string {
+	refs := make(map[string]string)
+	content, err := os.ReadFile(filepath.Join(commonDir, "packed-refs"))
+	if err != nil {
+		return refs
+	}
+	last := ""
+	for _, line := range strings.Split(string(content), "\n") {
+		switch {
+		case line == "" || line[0] == '#':
+		case line[0] == '^':
+			if last != "" {
+				refs[last] = line[1:]
+			}
+		default:
+			id, ref, ok := strings.Cut(line, " ")
+			if !ok {
+				continue
+			}
+			refs[ref] = id
+			last = ref
+		}
+	}
+	return refs
+}
+
+// peelTag follows annotated tag objects to the object they tag. Only loose objects are
+// read; ok is false when the object is packed.
+func peelTag(commonDir, id string) (string, bool) {
+	for depth := 0; depth < 8; depth++ {
+		file, err := os.Open(filepath.Join(commonDir, "objects", id[:2], id[2:]))
+		if err != nil {
+			return id, false
+		}
+		header := make([]byte, 128)
+		n := 0
+		if zr, err := zlib.NewReader(file); err == nil {
+			n, _ = io.ReadFull(zr, header)
+			zr.Close()
+		}
+		file.Close()
+
+		// A tag object starts "tag <size>\x00object <id>\n"
+		content, ok := strings.CutPrefix(string(header[:n]), "tag ")
+		if !ok {
+			return id, true
+		}
+		_, content, _ = strings.Cut(content, "\x00")
+		target, _, _ := strings.Cut(strings.TrimPrefix(content, "object "), "\n")
+		if !isObjectID(target) {
+			return id, true
+		}
+		id = target
+	}
+	return id, true
+}
@@ -0,0 +1,368 @@
+package source
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFile creates path and its parent directories
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestFindGitDir tests plain repositories, linked worktrees and submodules
+func TestFindGitDir(t *testing.T) {
+	root := t.TempDir()
+	mainDir := filepath.Join(root, "main")
+	mainGit := filepath.Join(mainDir, ".git")
+	writeFile(t, filepath.Join(mainGit, "HEAD"), "ref: refs/heads/master\n")
+	writeFile(t, filepath.Join(mainGit, "logs", "refs", "stash"), "0000 1111 A <a@b> 1 +0000\tWIP on master\n2222 3333 A <a@b> 2 +0000\tWIP on master\n")
+
+	// Linked worktree: .git file with an absolute gitdir, commondir relative to it
+	wtGit := filepath.Join(mainGit, "worktrees", "wt-auth")
+	writeFile(t, filepath.Join(wtGit, "HEAD"), "ref: refs/heads/feature/auth\n")
+	writeFile(t, filepath.Join(wtGit, "commondir"), "../..\n")
+	writeFile(t, filepath.Join(root, "wt-auth", ".git"), "gitdir: "+wtGit+"\n")
+
+	// Submodule: .git file with a relative gitdir
+	subGit := filepath.Join(mainGit, "modules", "lib")
+	writeFile(t, filepath.Join(subGit, "HEAD"), "ref: refs/heads/main\n")
+	writeFile(t, filepath.Join(mainDir, "lib", ".git"), "gitdir: ../.git/modules/lib\n")
+	if err := os.MkdirAll(filepath.Join(mainDir, "lib", "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, filepath.Join(root, "broken", ".git"), "gitdir: missing\n")
+
+	tests := []struct {
+		name         string
+		dir          string
+		wantGitDir   string
+		wantCommon   string
+		wantWorktree string
+		wantBranch   string
+		wantStashed  int
+	}{
+		{name: "main checkout", dir: mainDir, wantGitDir: mainGit, wantCommon: mainGit, wantBranch: "master", wantStashed: 2},
+		{name: "linked worktree", dir: filepath.Join(root, "wt-auth"), wantGitDir: wtGit, wantCommon: mainGit,
+			wantWorktree: "wt-auth", wantBranch: "feature/auth", wantStashed: 2},
+		{name: "submodule subdirectory", dir: filepath.Join(mainDir, "lib", "src"), wantGitDir: subGit, wantCommon: subGit,
+			wantBranch: "main"},
+		{name: "broken gitdir", dir: filepath.Join(root, "broken")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, ok := findGitDir(tt.dir)
+			if ok != (tt.wantGitDir != "") {
+				t.Fatalf("findGitDir() ok = %v, want %v", ok, tt.wantGitDir != "")
+			}
+			if repo.gitDir != tt.wantGitDir || repo.commonDir != tt.wantCommon {
+				t.Errorf("findGitDir() = %+v, want git dir %s, common dir %s", repo, tt.wantGitDir, tt.wantCommon)
+			}
+			if got := repo.worktree(); got != tt.wantWorktree {
+				t.Errorf("worktree() = %q, want %q", got, tt.wantWorktree)
+			}
+			if !ok {
+				return
+			}
+			info, _ := ReadGitInfo(tt.dir, nil)
+			if info.Branch != tt.wantBranch || info.Worktree != tt.wantWorktree || info.Stashed != tt.wantStashed {
+				t.Errorf("ReadGitInfo() = %+v, want branch %q, worktree %q, %d stashed",
+					info, tt.wantBranch, tt.wantWorktree, tt.wantStashed)
+			}
+		})
+	}
+}
+
+// TestParseGitStatus tests counting porcelain v2 entries and reading the branch headers
+func TestParseGitStatus(t *testing.T) {
+	entries := `1 M. N... 100644 100644 100644 aaa bbb staged.go
+1 .M N... 100644 100644 100644 aaa aaa modified.go
+1 MM N... 100644 100644 100644 aaa bbb both.go
+2 R. N... 100644 100644 100644 aaa aaa R100 new.go	old.go
+u UU N... 100644 100644 100644 100644 aaa bbb ccc conflict.go
+? notes.txt
+? tmp/
+! ignored.log
+`
+
+	tests := []struct {
+		name   string
+		output string
+		want   GitStatus
+	}{
+		{
+			name:   "entries with upstream",
+			output: "# branch.oid 1a2b3c\n# branch.head main\n# branch.upstream origin/main\n# branch.ab +1 -0\n" + entries,
+			want: GitStatus{Changes: 7, Staged: 3, Modified: 2, Untracked: 2, Conflicted: 1,
+				Upstream: "origin/main", Ahead: 1},
+		},
+		{
+			name:   "diverged",
+			output: "# branch.oid 1a2b3c\n# branch.head feature/x y\n# branch.upstream origin/feature/x y\n# branch.ab +3 -12\n",
+			want:   GitStatus{Upstream: "origin/feature/x y", Ahead: 3, Behind: 12},
+		},
+		{
+			name:   "upstream gone",
+			output: "# branch.oid 1a2b3c\n# branch.head topic\n# branch.upstream origin/topic\n",
+			want:   GitStatus{Upstream: "origin/topic"},
+		},
+		{
+			name:   "no upstream",
+			output: "# branch.oid (initial)\n# branch.head main\n",
+			want:   GitStatus{NoUpstream: true},
+		},
+		{
+			name:   "detached",
+			output: "# branch.oid 1a2b3c\n# branch.head (detached)\n",
+			want:   GitStatus{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseGitStatus([]byte(tt.output)); got != tt.want {
+				t.Errorf("parseGitStatus() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestReadGitOperation tests detecting operations left in progress
+func TestReadGitOperation(t *testing.T) {
+	tests := []struct {
+		name       string
+		files      map[string]string
+		want       GitOperation
+		wantBranch string
+	}{
+		{name: "idle"},
+		{
+			name: "interactive rebase",
+			files: map[string]string{
+				"rebase-merge/msgnum": "3\n", "rebase-merge/end": "7\n",
+				"rebase-merge/head-name": "refs/heads/feature/auth\n",
+			},
+			want:       GitOperation{Name: "REBASE", Step: 3, Total: 7},
+			wantBranch: "feature/auth",
+		},
+		{
+			name: "apply rebase",
+			files: map[string]string{
+				"rebase-apply/next": "2\n", "rebase-apply/last": "5\n", "rebase-apply/rebasing": "",
+				"rebase-apply/head-name": "refs/heads/topic\n",
+			},
+			want:       GitOperation{Name: "REBASE", Step: 2, Total: 5},
+			wantBranch: "topic",
+		},
+		{
+			name:  "am",
+			files: map[string]string{"rebase-apply/next": "1\n", "rebase-apply/last": "4\n", "rebase-apply/applying": ""},
+			want:  GitOperation{Name: "AM", Step: 1, Total: 4},
+		},
+		{
+			name:  "rebase of detached head",
+			files: map[string]string{"rebase-merge/msgnum": "1\n", "rebase-merge/end": "2\n", "rebase-merge/head-name": "detached HEAD\n"},
+			want:  GitOperation{Name: "REBASE", Step: 1, Total: 2},
+		},
+		{name: "merge", files: map[string]string{"MERGE_HEAD": "abc\n"}, want: GitOperation{Name: "MERGING"}},
+		{name: "cherry-pick", files: map[string]string{"CHERRY_PICK_HEAD": "abc\n"}, want: GitOperation{Name: "CHERRY-PICKING"}},
+		{name: "revert", files: map[string]string{"REVERT_HEAD": "abc\n"}, want: GitOperation{Name: "REVERTING"}},
+		{name: "bisect", files: map[string]string{"BISECT_LOG": "git bisect start\n"}, want: GitOperation{Name: "BISECTING"}},
+		{
+			name:  "rebase wins over merge",
+			files: map[string]string{"MERGE_HEAD": "abc\n", "rebase-merge/msgnum": "1\n", "rebase-merge/end": "1\n"},
+			want:  GitOperation{Name: "REBASE", Step: 1, Total: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gitDir := t.TempDir()
+			for name, content := range tt.files {
+				writeFile(t, filepath.Join(gitDir, name), content)
+			}
+			got, branch := readGitOperation(gitDir)
+			if got != tt.want || branch != tt.wantBranch {
+				t.Errorf("readGitOperation() = %+v, %q, want %+v, %q", got, branch, tt.want, tt.wantBranch)
+			}
+		})
+	}
+}
+
+// writeTagObject stores a loose annotated tag object pointing at target
+func writeTagObject(t *testing.T, gitDir, id, target string) {
+	t.Helper()
+	body := "object " + target + "\ntype commit\ntag v2.0.0\n"
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	fmt.Fprintf(zw, "tag %d\x00%s", len(body), body)
+	zw.Close()
+	writeFile(t, filepath.Join(gitDir, "objects", id[:2], id[2:]), buf.String())
+}
+
+// TestDetachedName tests naming a detached HEAD after tags and remote branches
+func TestDetachedName(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	commit := func(c string) string { return strings.Repeat(c, 40) }
+	sha256 := strings.Repeat("e", 64)
+	tagObject := strings.Repeat("9", 40)
+
+	tests := []struct {
+		name  string
+		head  string
+		files map[string]string
+		want  string
+	}{
+		{name: "no refs", head: commit("a"), want: "aaaaaaa"},
+		{
+			name: "packed lightweight tag",
+			head: commit("a"),
+			files: map[string]string{"packed-refs": "# pack-refs with: peeled fully-peeled sorted\n" +
+				commit("a") + " refs/heads/main\n" + commit("a") + " refs/tags/v1.4.2\n"},
+			want: "v1.4.2",
+		},
+		{
+			name: "packed annotated tag",
+			head: commit("a"),
+			files: map[string]string{"packed-refs": "# pack-refs with: peeled fully-peeled sorted\n" +
+				commit("b") + " refs/tags/v1.0.0\n" + commit("c") + " refs/tags/v1.4.2\n^" + commit("a") + "\n"},
+			want: "v1.4.2",
+		},
+		{
+			name: "loose remote branch",
+			head: commit("a"),
+			files: map[string]string{
+				"refs/remotes/origin/HEAD": "ref: refs/remotes/origin/main\n",
+				"refs/remotes/origin/main": commit("a") + "\n",
+			},
+			want: "origin/main",
+		},
+		{
+			name: "tag wins over remote branch",
+			head: commit("a"),
+			files: map[string]string{
+				"refs/remotes/origin/main": commit("a") + "\n",
+				"refs/tags/v1.4.2":         commit("a") + "\n",
+			},
+			want: "v1.4.2",
+		},
+		{
+			name: "loose ref overrides packed ref",
+			head: commit("a"),
+			files: map[string]string{
+				"packed-refs":      commit("a") + " refs/tags/v1.0.0\n",
+				"refs/tags/v1.0.0": commit("b") + "\n",
+			},
+			want: "aaaaaaa",
+		},
+		{
+			name:  "loose annotated tag",
+			head:  commit("a"),
+			files: map[string]string{"refs/tags/v2.0.0": tagObject + "\n"},
+			want:  "v2.0.0",
+		},
+		{
+			name:  "sha-256",
+			head:  sha256,
+			files: map[string]string{"packed-refs": sha256 + " refs/remotes/upstream/release\n"},
+			want:  "upstream/release",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			gitDir := filepath.Join(dir, ".git")
+			writeFile(t, filepath.Join(gitDir, "HEAD"), tt.head+"\n")
+			writeTagObject(t, gitDir, tagObject, commit("a"))
+			for name, content := range tt.files {
+				writeFile(t, filepath.Join(gitDir, name), content)
+			}
+			info, ok := ReadGitInfo(dir, nil)
+			if !ok || info.Branch != tt.want {
+				t.Errorf("ReadGitInfo() branch = %q, %v, want %q", info.Branch, ok, tt.want)
+			}
+		})
+	}
+}
+
+// TestReadGitInfoReftable tests a repository whose refs are kept in a reftable
+func TestReadGitInfoReftable(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := t.TempDir()
+	git := func(args ...string) error {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=a", "-c", "user.email=a@b"}, args...)...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, output)
+		}
+		return nil
+	}
+	if err := git("init", "-q", "--ref-format=reftable", "-b", "main"); err != nil {
+		t.Skip("git does not support reftable")
+	}
+	if err := git("commit", "-q", "--allow-empty", "-m", "first"); err != nil {
+		t.Fatal(err)
+	}
+
+	if info, ok := ReadGitInfo(dir, nil); !ok || info.Branch != "main" {
+		t.Errorf("ReadGitInfo() branch = %q, %v, want main", info.Branch, ok)
+	}
+
+	if err := git("tag", "-a", "-m", "release", "v1.4.2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := git("checkout", "-q", "--detach"); err != nil {
+		t.Fatal(err)
+	}
+	if info, ok := ReadGitInfo(dir, nil); !ok || info.Branch != "v1.4.2" {
+		t.Errorf("ReadGitInfo() detached branch = %q, %v, want v1.4.2", info.Branch, ok)
+	}
+}
+
+// TestGitStatusInvalidation tests that a cached status is dropped as soon as the
+// index or refs change, without waiting for the cache TTL
+func TestGitStatusInvalidation(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=a", "-c", "user.email=a@b"}, args...)...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, output)
+		}
+	}
+	git("init", "-q", "-b", "main")
+	git("commit", "-q", "--allow-empty", "-m", "first")
+	git("branch", "base")
+	writeFile(t, filepath.Join(dir, "a.txt"), "a")
+
+	if info, _ := ReadGitInfo(dir, nil); info.Untracked != 1 || info.Staged != 0 {
+		t.Fatalf("ReadGitInfo() = %+v, want one untracked file", info.GitStatus)
+	}
+	git("add", "a.txt")
+	if info, _ := ReadGitInfo(dir, nil); info.Untracked != 0 || info.Staged != 1 {
+		t.Errorf("ReadGitInfo() after git add = %+v, want one staged file", info.GitStatus)
+	}
+	git("commit", "-q", "-m", "second")
+	git("branch", "-q", "--set-upstream-to=base")
+	if info, _ := ReadGitInfo(dir, nil); info.Staged != 0 || info.Upstream != "base" || info.Ahead != 1 {
+		t.Errorf("ReadGitInfo() after commit = %+v, want clean and one ahead of base", info.GitStatus)
+	}
+}
@@ -0,0 +1,149 @@
+package source
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mrdavidaylward/ccstatus/internal/cache"
+	"github.com/mrdavidaylward/ccstatus/schema"
+	"github.com/mrdavidaylward/ccstatus/usage"
+)
+
+// TimeToReset returns the time left until usage resets, such as "2h 15m", and which
+// reset that is: "5hr" for the rolling window, or "daily" when no session start is known
+func TimeToReset(env schema.Env) (string, string) {
+	// Claude uses 5-hour rolling windows, not fixed daily resets
+	// The window starts with your first prompt and resets 5 hours later
+
+	// Try to get actual session start time from ccusage or estimate
+	sessionStartTime := SessionStartTime(env)
+	now := time.Now()
+
+	if !sessionStartTime.IsZero() {
+		// Calculate time since session started
+		elapsed := now.Sub(sessionStartTime)
+		fiveHours := 5 * time.Hour
+
+		if elapsed < fiveHours {
+			// Still in current 5-hour window
+			remaining := fiveHours - elapsed
+			hours := int(remaining.Hours())
+			minutes := int(remaining.Minutes()) % 60
+
+			var timeStr string
+			if hours > 0 {
+				timeStr = fmt.Sprintf("%dh %dm", hours, minutes)
+			} else {
+				timeStr = fmt.Sprintf("%dm", minutes)
+			}
+			return timeStr, "5hr"
+		} else {
+			// Session has expired - we're in a new window, reset tracking
+			// Update session start time to now for the new window
+			updateSessionStartTime(now, env)
+			return "5h 0m", "5hr"
+		}
+	}
+
+	// Fallback: estimate based on daily reset (if no session data available)
+	currentHour := now.Hour()
+	currentMin := now.Minute()
+	currentSec := now.Second()
+
+	secondsSinceMidnight := currentHour*3600 + currentMin*60 + currentSec
+	secondsToDailyReset := 86400 - secondsSinceMidnight
+
+	hoursToReset := secondsToDailyReset / 3600
+	minutesToReset := (secondsToDailyReset % 3600) / 60
+
+	var timeStr string
+	if hoursToReset > 0 {
+		timeStr = fmt.Sprintf("%dh %dm", hoursToReset, minutesToReset)
+	} else {
+		timeStr = fmt.Sprintf("%dm", minutesToReset)
+	}
+
+	return timeStr, "daily"
+}
+
+// SessionStartTime tries to determine when the current 5-hour session started
+func SessionStartTime(env schema.Env) time.Time {
+	// Prefer the active block from the native usage aggregator
+	if summary, ok := usage.CachedSummary(env); ok {
+		if !summary.Block.Start.IsZero() {
+			return summary.Block.Start
+		}
+	} else if _, err := lookPath(env, "ccusage"); err == nil {
+		// Try to get session start from ccusage active blocks command
+		var startTime time.Time
+		if cache.Get(env, "ccusage", "block-start", ccusageCacheTTL, &startTime) {
+			if !startTime.IsZero() {
+				return startTime
+			}
+		} else {
+			cmd := command(env, "ccusage", "blocks", "--active", "--json")
+			if output, err := cmd.Output(); err == nil {
+				// Parse JSON to find current active block start time
+				startTime = extractSessionStartFromCCUsage(string(output))
+			}
+			cache.Put(env, "ccusage", "block-start", startTime)
+			if !startTime.IsZero() {
+				return startTime
+			}
+		}
+	}
+
+	// Fallback: check for session start time file
+	homeDir, err := env.HomeDir()
+	if err == nil {
+		sessionStartFile := filepath.Join(homeDir, ".claude", "session_start")
+		if content, err := os.ReadFile(sessionStartFile); err == nil {
+			if startTime, err := time.Parse(time.RFC3339, strings.TrimSpace(string(content))); err == nil {
+				return startTime
+			}
+		}
+	}
+
+	return time.Time{} // Zero time if no session data found
+}
+
+// updateSessionStartTime updates the session start time file for new 5hr window
+// Uses atomic write to prevent race conditions
+func updateSessionStartTime(startTime time.Time, env schema.Env) {
+	homeDir, err := env.HomeDir()
+	if err != nil {
+		return
+	}
+
+	claudeDir := filepath.Join(homeDir, ".claude")
+	sessionStartFile := filepath.Join(claudeDir, "session_start")
+	timeStr := startTime.Format(time.RFC3339)
+
+	// Ensure .claude directory exists
+	os.MkdirAll(claudeDir, 0755)
+
+	// Atomic write: write to temp file, then rename
+	tmpFile := sessionStartFile + ".tmp"
+	if err := os.WriteFile(tmpFile, []byte(timeStr), 0644); err != nil {
+		return
+	}
+	// Rename is atomic on Unix systems
+	os.Rename(tmpFile, sessionStartFile)
+}
+
+// extractSessionStartFromCCUsage parses ccusage blocks output to find active session start
+func extractSessionStartFromCCUsage(jsonOutput string) time.Time {
+	// Look for startTime in active block: "startTime": "2025-09-02T09:00:00.000Z"
+	re := regexp.MustCompile(`"startTime"\s*:\s*"([^"]+)"`)
+	matches := re.FindStringSubmatch(jsonOutput)
+	if len(matches) > 1 {
+		if startTime, err := time.Parse(time.RFC3339, matches[1]); err == nil {
+			return startTime
+		}
+	}
+	return time.Time{}
+}
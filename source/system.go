@@ -0,0 +1,26 @@
+package source
+
+import (
+	"os"
+	"os/user"
+	"strings"
+)
+
+// Username returns the current user's login name, or "user" if it can't be determined
+func Username() string {
+	if currentUser, err := user.Current(); err == nil {
+		return currentUser.Username
+	}
+	return "user"
+}
+
+// Hostname returns the machine's short host name (up to the first dot), or "localhost"
+func Hostname() string {
+	if hostname, err := os.Hostname(); err == nil {
+		if dotIndex := strings.Index(hostname, "."); dotIndex > 0 {
+			return hostname[:dotIndex]
+		}
+		return hostname
+	}
+	return "localhost"
+}
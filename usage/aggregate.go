@@ -0,0 +1,395 @@
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mrdavidaylward/ccstatus/internal/cache"
+	"github.com/mrdavidaylward/ccstatus/internal/debug"
+	"github.com/mrdavidaylward/ccstatus/schema"
+)
+
+// Native usage aggregation over every Claude Code project transcript.
+// Mirrors ccusage: entries are deduplicated by message and request ID, and
+// 5-hour blocks start at the first entry's timestamp floored to the hour.
+
+// Cache structures for performance optimization
+type cachedResult struct {
+	key       string // Environment the result was computed for
+	data      interface{}
+	timestamp time.Time
+}
+
+// summaryCacheTTL is how long a usage summary is reused before rescanning
+const summaryCacheTTL = 2 * time.Second
+
+var (
+	usageSummaryCache    *cachedResult
+	usageSummaryCacheMux sync.Mutex
+)
+
+const (
+	usageIndexVersion = 1
+
+	// Entries older than this cannot affect the weekly total or the active block
+	usageRetention = 8 * 24 * time.Hour
+
+	blockDuration = RateWindowSeconds * time.Second
+)
+
+// usageEntry is a deduplicated assistant response kept in the index
+type usageEntry struct {
+	Timestamp time.Time  `json:"t"`
+	Model     string     `json:"m,omitempty"`
+	Key       string     `json:"k,omitempty"`
+	Usage     TokenUsage `json:"u"`
+}
+
+// indexedFile records how far a transcript has been read
+type indexedFile struct {
+	Offset  int64     `json:"offset"`
+	ModTime time.Time `json:"mtime"`
+}
+
+// usageIndex is the on-disk incremental index of recent usage entries
+type usageIndex struct {
+	Version int                    `json:"version"`
+	Files   map[string]indexedFile `json:"files"`
+	Entries []usageEntry           `json:"entries"`
+}
+
+// BlockUsage describes a 5-hour billing block
+type BlockUsage struct {
+	Start        time.Time
+	End          time.Time
+	LastActivity time.Time
+	Usage        TokenUsage
+	Entries      int
+}
+
+// UsageSummary is the aggregate of all projects' recent usage
+type UsageSummary struct {
+	Block  BlockUsage            // Active 5-hour block; zero Start when idle
+	Daily  TokenUsage            // Since local midnight
+	Weekly TokenUsage            // Since the last weekly reset
+	Models map[string]TokenUsage // Weekly totals per model ID
+}
+
+// IsActive reports whether the block is still open at now
+func (b BlockUsage) IsActive(now time.Time) bool {
+	return !b.Start.IsZero() && now.Before(b.End) && now.Sub(b.LastActivity) < blockDuration
+}
+
+// getClaudeProjectsDirs returns the transcript roots, honoring CLAUDE_CONFIG_DIR
+func getClaudeProjectsDirs(env schema.Env) []string {
+	var roots []string
+	if configDirs := env.Getenv("CLAUDE_CONFIG_DIR"); configDirs != "" {
+		for _, dir := range strings.Split(configDirs, ",") {
+			if dir = strings.TrimSpace(dir); dir != "" {
+				roots = append(roots, dir)
+			}
+		}
+	} else if homeDir, err := env.HomeDir(); err == nil {
+		roots = append(roots, filepath.Join(homeDir, ".claude"))
+	}
+
+	var dirs []string
+	for _, root := range roots {
+		projectsDir := filepath.Join(root, "projects")
+		if info, err := os.Stat(projectsDir); err == nil && info.IsDir() {
+			dirs = append(dirs, projectsDir)
+		}
+	}
+	return dirs
+}
+
+// usageIndexPath returns the index file for a set of projects directories; each set
+// gets its own, so clients with different CLAUDE_CONFIG_DIRs never mix entries
+func usageIndexPath(cacheDir string, dirs []string) string {
+	return filepath.Join(cacheDir, "usage-index-"+cache.Key(dirs...)+".json")
+}
+
+// getUsageSummary updates the usage index for dirs and summarizes it
+func getUsageSummary(env schema.Env, dirs []string) UsageSummary {
+	now := time.Now()
+	indexPath := ""
+	if cacheDir := cache.Dir(env); cacheDir != "" {
+		indexPath = usageIndexPath(cacheDir, dirs)
+	}
+
+	// Hold the lock across load, update and save so concurrent windows don't drop entries
+	savePath := indexPath
+	if indexPath != "" {
+		release, err := cache.AcquireLock(indexPath)
+		if err != nil {
+			debug.Log("Usage index is busy, updating without saving: %v", err)
+			savePath = ""
+		} else {
+			defer release()
+		}
+	}
+
+	index := loadUsageIndex(indexPath)
+	if err := index.update(dirs, now); err != nil {
+		debug.Log("Failed to update usage index: %v", err)
+	}
+	if err := index.save(savePath); err != nil {
+		debug.Log("Failed to save usage index: %v", err)
+	}
+
+	return summarizeUsage(index.Entries, now)
+}
+
+// loadUsageIndex reads the index from path, returning an empty index if unusable
+func loadUsageIndex(path string) *usageIndex {
+	index := &usageIndex{Version: usageIndexVersion, Files: make(map[string]indexedFile)}
+	if path == "" {
+		return index
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return index
+	}
+
+	var loaded usageIndex
+	if err := json.Unmarshal(content, &loaded); err != nil || loaded.Version != usageIndexVersion {
+		debug.Log("Discarding usage index at %s", path)
+		return index
+	}
+	if loaded.Files == nil {
+		loaded.Files = make(map[string]indexedFile)
+	}
+	return &loaded
+}
+
+// save writes the index atomically
+func (idx *usageIndex) save(path string) error {
+	if path == "" {
+		return nil
+	}
+	content, err := json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+	return cache.WriteFileAtomic(path, content)
+}
+
+// update reads new lines from every transcript under dirs and prunes old entries
+func (idx *usageIndex) update(dirs []string, now time.Time) error {
+	cutoff := now.Add(-usageRetention)
+
+	seen := make(map[string]bool, len(idx.Entries))
+	for _, entry := range idx.Entries {
+		if entry.Key != "" {
+			seen[entry.Key] = true
+		}
+	}
+
+	present := make(map[string]bool)
+	var firstErr error
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(path, ".jsonl") {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			present[path] = true
+
+			prev, known := idx.Files[path]
+			if !known && info.ModTime().Before(cutoff) {
+				// Untouched for longer than retention: nothing in it can count
+				idx.Files[path] = indexedFile{Offset: info.Size(), ModTime: info.ModTime()}
+				return nil
+			}
+			if known && info.Size() == prev.Offset && info.ModTime().Equal(prev.ModTime) {
+				return nil
+			}
+			if info.Size() < prev.Offset {
+				// Rewritten; dedup keys prevent double counting on re-read
+				prev.Offset = 0
+			}
+
+			entries, offset, err := readUsageEntries(path, prev.Offset)
+			if err != nil {
+				debug.Log("Failed to read %s: %v", path, err)
+				return nil
+			}
+			for _, entry := range entries {
+				if entry.Timestamp.Before(cutoff) {
+					continue
+				}
+				if entry.Key != "" {
+					if seen[entry.Key] {
+						continue
+					}
+					seen[entry.Key] = true
+				}
+				idx.Entries = append(idx.Entries, entry)
+			}
+			idx.Files[path] = indexedFile{Offset: offset, ModTime: info.ModTime()}
+			return nil
+		})
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	for path := range idx.Files {
+		if !present[path] {
+			delete(idx.Files, path)
+		}
+	}
+
+	kept := idx.Entries[:0]
+	for _, entry := range idx.Entries {
+		if !entry.Timestamp.Before(cutoff) {
+			kept = append(kept, entry)
+		}
+	}
+	idx.Entries = kept
+
+	return firstErr
+}
+
+// readUsageEntries reads complete lines from offset and returns the new offset.
+// A trailing line without a newline is still being written and is left for the next run.
+func readUsageEntries(path string, offset int64) ([]usageEntry, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, offset, err
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, offset, err
+	}
+
+	var entries []usageEntry
+	reader := bufio.NewReaderSize(file, 64*1024)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			return entries, offset, nil
+		}
+		if err != nil {
+			return entries, offset, err
+		}
+		offset += int64(len(line))
+
+		entry, ok := parseTranscriptLine(line)
+		if !ok || entry.Type != "assistant" || entry.Message == nil || entry.Message.Usage == nil {
+			continue
+		}
+		entries = append(entries, usageEntry{
+			Timestamp: entry.Timestamp,
+			Model:     entry.Message.Model,
+			Key:       entry.dedupKey(),
+			Usage:     *entry.Message.Usage,
+		})
+	}
+}
+
+// summarizeUsage computes the active block, daily, weekly and per-model totals
+func summarizeUsage(entries []usageEntry, now time.Time) UsageSummary {
+	summary := UsageSummary{Models: make(map[string]TokenUsage)}
+
+	sorted := make([]usageEntry, len(entries))
+	copy(sorted, entries)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	weekStart := WeeklyResetTime(now).AddDate(0, 0, -7)
+
+	var block BlockUsage
+	for _, entry := range sorted {
+		if entry.Timestamp.After(now) {
+			continue
+		}
+
+		// A new block starts when the current one has ended or activity paused for 5 hours
+		if block.Start.IsZero() || !entry.Timestamp.Before(block.End) ||
+			entry.Timestamp.Sub(block.LastActivity) >= blockDuration {
+			start := entry.Timestamp.UTC().Truncate(time.Hour)
+			block = BlockUsage{Start: start, End: start.Add(blockDuration)}
+		}
+		block.Usage.Add(entry.Usage)
+		block.Entries++
+		block.LastActivity = entry.Timestamp
+
+		if !entry.Timestamp.Before(dayStart) {
+			summary.Daily.Add(entry.Usage)
+		}
+		if !entry.Timestamp.Before(weekStart) {
+			summary.Weekly.Add(entry.Usage)
+			model := summary.Models[entry.Model]
+			model.Add(entry.Usage)
+			summary.Models[entry.Model] = model
+		}
+	}
+
+	if block.IsActive(now) {
+		summary.Block = block
+	}
+	return summary
+}
+
+// NativeUsageData adapts the native usage summary to CCUsageData
+func NativeUsageData(env schema.Env) (CCUsageData, bool) {
+	summary, ok := CachedSummary(env)
+	if !ok {
+		return CCUsageData{}, false
+	}
+
+	return CCUsageData{
+		SessionTokens: summary.Block.Usage.TotalTokens(),
+		DailyTokens:   summary.Daily.TotalTokens(),
+		WeeklyTokens:  summary.Weekly.TotalTokens(),
+		Messages:      summary.Block.Entries,
+		InputTokens:   summary.Block.Usage.InputTokens,
+		OutputTokens:  summary.Block.Usage.OutputTokens,
+	}, true
+}
+
+// CachedSummary memoizes getUsageSummary in memory for the render and on disk across
+// renders, keyed by env's projects directories; ok is false without transcripts
+func CachedSummary(env schema.Env) (UsageSummary, bool) {
+	dirs := getClaudeProjectsDirs(env)
+	if len(dirs) == 0 {
+		debug.Log("No Claude projects directory found")
+		return UsageSummary{}, false
+	}
+	key := cache.Key(append([]string{cache.Dir(env)}, dirs...)...)
+
+	usageSummaryCacheMux.Lock()
+	defer usageSummaryCacheMux.Unlock()
+
+	if usageSummaryCache != nil && usageSummaryCache.key == key && time.Since(usageSummaryCache.timestamp) < summaryCacheTTL {
+		summary, ok := usageSummaryCache.data.(UsageSummary)
+		return summary, ok
+	}
+
+	var summary UsageSummary
+	if !cache.Get(env, "usage", key, summaryCacheTTL, &summary) {
+		summary = getUsageSummary(env, dirs)
+		if err := cache.Put(env, "usage", key, summary); err != nil {
+			debug.Log("Failed to cache usage summary: %v", err)
+		}
+	}
+
+	usageSummaryCache = &cachedResult{key: key, data: summary, timestamp: time.Now()}
+	return summary, true
+}
@@ -0,0 +1,117 @@
+package usage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// assistantLine builds a transcript line for an assistant response
+func assistantLine(id string, ts time.Time, model string, input, output int) string {
+	return fmt.Sprintf(`{"type":"assistant","requestId":"req_%s","timestamp":%q,"message":{"id":"msg_%s","model":%q,"usage":{"input_tokens":%d,"output_tokens":%d}}}`+"\n",
+		id, ts.UTC().Format(time.RFC3339Nano), id, model, input, output)
+}
+
+// TestSummarizeUsage tests block detection, daily, weekly and per-model totals
+func TestSummarizeUsage(t *testing.T) {
+	// Wednesday afternoon, so the week started on Monday
+	now := time.Date(2025, 9, 3, 15, 30, 0, 0, time.UTC)
+	entry := func(ago time.Duration, model string, tokens int) usageEntry {
+		return usageEntry{Timestamp: now.Add(-ago), Model: model, Usage: TokenUsage{InputTokens: tokens}}
+	}
+
+	entries := []usageEntry{
+		entry(3*24*time.Hour, "opus", 1000),             // Sunday: before the weekly reset
+		entry(30*time.Hour, "sonnet", 200),              // Tuesday: in the week, earlier block
+		entry(2*time.Hour+10*time.Minute, "sonnet", 30), // 13:20: starts the active block at 13:00
+		entry(time.Hour, "opus", 40),
+		entry(10*time.Minute, "sonnet", 50),
+	}
+
+	got := summarizeUsage(entries, now)
+
+	wantStart := time.Date(2025, 9, 3, 13, 0, 0, 0, time.UTC)
+	if !got.Block.Start.Equal(wantStart) {
+		t.Errorf("summarizeUsage() block start = %v, want %v", got.Block.Start, wantStart)
+	}
+	if got.Block.Usage.InputTokens != 120 || got.Block.Entries != 3 {
+		t.Errorf("summarizeUsage() block = %d tokens / %d entries, want 120 / 3", got.Block.Usage.InputTokens, got.Block.Entries)
+	}
+	if got.Weekly.InputTokens != 320 {
+		t.Errorf("summarizeUsage() weekly = %d, want 320", got.Weekly.InputTokens)
+	}
+	if got.Models["sonnet"].InputTokens != 280 || got.Models["opus"].InputTokens != 40 {
+		t.Errorf("summarizeUsage() models = %+v", got.Models)
+	}
+	if got.Daily.InputTokens != 120 {
+		t.Errorf("summarizeUsage() daily = %d, want 120", got.Daily.InputTokens)
+	}
+}
+
+// TestSummarizeUsageIdle tests that no block is active after a 5-hour pause
+func TestSummarizeUsageIdle(t *testing.T) {
+	now := time.Date(2025, 9, 3, 15, 30, 0, 0, time.UTC)
+	entries := []usageEntry{{Timestamp: now.Add(-6 * time.Hour), Usage: TokenUsage{InputTokens: 10}}}
+
+	got := summarizeUsage(entries, now)
+	if !got.Block.Start.IsZero() {
+		t.Errorf("summarizeUsage() block start = %v, want none", got.Block.Start)
+	}
+}
+
+// TestUsageIndexIncremental tests that repeat updates only read appended lines
+func TestUsageIndexIncremental(t *testing.T) {
+	dir := t.TempDir()
+	projectDir := filepath.Join(dir, "-home-user-project")
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(projectDir, "session.jsonl")
+	now := time.Now()
+
+	// Second line is the same response split into another content block
+	first := assistantLine("1", now.Add(-time.Minute), "sonnet", 10, 5) + assistantLine("1", now.Add(-time.Minute), "sonnet", 10, 5)
+	if err := os.WriteFile(path, []byte(first), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	index := loadUsageIndex("")
+	if err := index.update([]string{dir}, now); err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Entries) != 1 {
+		t.Fatalf("update() entries = %d, want 1", len(index.Entries))
+	}
+	if index.Files[path].Offset != int64(len(first)) {
+		t.Errorf("update() offset = %d, want %d", index.Files[path].Offset, len(first))
+	}
+
+	// Append one complete line and one partial line still being written
+	partial := assistantLine("3", now, "sonnet", 1, 1)
+	appended := assistantLine("2", now, "opus", 20, 10) + partial[:20]
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(appended)
+	file.Close()
+
+	indexPath := usageIndexPath(filepath.Join(dir, "cache"), []string{dir})
+	if err := index.update([]string{dir}, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := index.save(indexPath); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := loadUsageIndex(indexPath)
+	if len(reloaded.Entries) != 2 {
+		t.Fatalf("reloaded entries = %d, want 2", len(reloaded.Entries))
+	}
+	wantOffset := int64(len(first) + len(appended) - 20)
+	if reloaded.Files[path].Offset != wantOffset {
+		t.Errorf("reloaded offset = %d, want %d (partial line excluded)", reloaded.Files[path].Offset, wantOffset)
+	}
+}
@@ -0,0 +1,21 @@
+package usage
+
+// Constants for Claude Pro Plan Limits (2025)
+const (
+	// Context window: Sonnet 4 now supports 1M tokens (standard 200K)
+	SonnetContextLimit   = 1000000 // 1M context window (beta with context-1m flag)
+	StandardContextLimit = 200000  // 200K standard context window
+
+	// Pro plan rolling rate limits (5-hour windows, resets every 5 hours)
+	// Approximately 45 messages per 5-hour window for typical conversations
+	MessagesPerWindow = 45    // Messages per 5 hour window
+	RateWindowSeconds = 18000 // 5 hours in seconds (5 * 60 * 60)
+
+	// Weekly limits (August 2025 update - resets every 7 days)
+	// Pro users get 40-80 hours of Sonnet 4 usage per week
+	WeeklyTokenEstimate = 5000000 // Conservative estimate for weekly tokens
+	SecondsInWeek       = 604800  // 7 days in seconds (7 * 24 * 60 * 60)
+
+	// Output token limits
+	MaxOutputTokens = 64000 // Max output tokens for Sonnet 4
+)
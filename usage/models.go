@@ -0,0 +1,148 @@
+package usage
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/mrdavidaylward/ccstatus/internal/debug"
+	"github.com/mrdavidaylward/ccstatus/schema"
+)
+
+// ModelSpec describes a model's context window, output limit and built-in pricing
+type ModelSpec struct {
+	ContextWindow         int // Standard context window in tokens
+	ExtendedContextWindow int // 1M window available via [1m] variants, 0 if unsupported
+	MaxOutput             int // Maximum output tokens per response
+	Pricing               ModelPricing
+}
+
+// modelRegistry maps model IDs (without date suffix) to their limits and pricing (USD per 1M tokens)
+var modelRegistry = map[string]ModelSpec{
+	"claude-opus-4-5": {ContextWindow: StandardContextLimit, MaxOutput: 64000,
+		Pricing: ModelPricing{PricingRates: PricingRates{Input: 5, Output: 25, CacheWrite5m: 6.25, CacheWrite1h: 10, CacheRead: 0.50}}},
+	"claude-opus-4-1": {ContextWindow: StandardContextLimit, MaxOutput: 32000, Pricing: opus4Pricing},
+	"claude-opus-4":   {ContextWindow: StandardContextLimit, MaxOutput: 32000, Pricing: opus4Pricing},
+	"claude-3-opus":   {ContextWindow: StandardContextLimit, MaxOutput: 4096, Pricing: opus4Pricing},
+	"claude-sonnet-4-5": {ContextWindow: StandardContextLimit, ExtendedContextWindow: SonnetContextLimit, MaxOutput: 64000,
+		Pricing: sonnet4Pricing},
+	"claude-sonnet-4": {ContextWindow: StandardContextLimit, ExtendedContextWindow: SonnetContextLimit, MaxOutput: 64000,
+		Pricing: sonnet4Pricing},
+	"claude-3-7-sonnet": {ContextWindow: StandardContextLimit, MaxOutput: 64000, Pricing: sonnet3Pricing},
+	"claude-3-5-sonnet": {ContextWindow: StandardContextLimit, MaxOutput: 8192, Pricing: sonnet3Pricing},
+	"claude-haiku-4-5": {ContextWindow: StandardContextLimit, MaxOutput: 64000,
+		Pricing: ModelPricing{PricingRates: PricingRates{Input: 1, Output: 5, CacheWrite5m: 1.25, CacheWrite1h: 2, CacheRead: 0.10}}},
+	"claude-3-5-haiku": {ContextWindow: StandardContextLimit, MaxOutput: 8192,
+		Pricing: ModelPricing{PricingRates: PricingRates{Input: 0.80, Output: 4, CacheWrite5m: 1, CacheWrite1h: 1.60, CacheRead: 0.08}}},
+	"claude-3-haiku": {ContextWindow: StandardContextLimit, MaxOutput: 4096,
+		Pricing: ModelPricing{PricingRates: PricingRates{Input: 0.25, Output: 1.25, CacheWrite5m: 0.30, CacheWrite1h: 0.50, CacheRead: 0.03}}},
+}
+
+// Pricing shared by several models
+var (
+	opus4Pricing   = ModelPricing{PricingRates: PricingRates{Input: 15, Output: 75, CacheWrite5m: 18.75, CacheWrite1h: 30, CacheRead: 1.50}}
+	sonnet3Pricing = ModelPricing{PricingRates: PricingRates{Input: 3, Output: 15, CacheWrite5m: 3.75, CacheWrite1h: 6, CacheRead: 0.30}}
+
+	// sonnet4Pricing includes the >200K long-context tier
+	sonnet4Pricing = ModelPricing{
+		PricingRates: sonnet3Pricing.PricingRates,
+		LongContext:  &PricingRates{Input: 6, Output: 22.50, CacheWrite5m: 7.50, CacheWrite1h: 12, CacheRead: 0.60},
+	}
+)
+
+// latestModels names the newest model of each family, whose limits and pricing apply
+// when only a display name like "Sonnet 4" is known
+var latestModels = map[string]string{
+	"sonnet": "claude-sonnet-4-5",
+	"opus":   "claude-opus-4-5",
+	"haiku":  "claude-haiku-4-5",
+}
+
+var modelDateSuffix = regexp.MustCompile(`-\d{8}$`)
+
+// normalizeModelID strips context-window markers and date suffixes from a model ID
+func normalizeModelID(model string) string {
+	model = strings.ToLower(strings.TrimSpace(model))
+	if i := strings.Index(model, "["); i >= 0 {
+		model = model[:i]
+	}
+	return modelDateSuffix.ReplaceAllString(model, "")
+}
+
+// lookupModel finds a model in a table keyed by model ID without date suffix. An ID
+// the table doesn't list, such as a display name or a model newer than this build,
+// resolves to its family's newest model rather than an older model sharing a prefix,
+// whose rates may be out of date. ok is false if the table lacks that model too.
+func lookupModel[V any](table map[string]V, model string) (V, bool) {
+	id := normalizeModelID(model)
+	if value, ok := table[id]; ok {
+		return value, true
+	}
+
+	latest := latestModels[modelFamily(id)]
+	debug.Log("Model %q is not in the registry, using %s", model, latest)
+	value, ok := table[latest]
+	return value, ok
+}
+
+// modelFamily returns sonnet, opus or haiku for a model ID or display name, defaulting to sonnet
+func modelFamily(model string) string {
+	id := normalizeModelID(model)
+	for _, family := range []string{"sonnet", "opus", "haiku"} {
+		if strings.Contains(id, family) {
+			return family
+		}
+	}
+	return "sonnet"
+}
+
+// LookupModelSpec finds a model by ID, falling back to the newest model of its family
+func LookupModelSpec(model string) ModelSpec {
+	spec, _ := lookupModel(modelRegistry, model)
+	return spec
+}
+
+// ActiveModel is the session's model with its limits and pricing resolved
+type ActiveModel struct {
+	ID           string
+	Spec         ModelSpec
+	Pricing      ModelPricing
+	ContextLimit int // Context window in effect for this session
+}
+
+// ResolveActiveModel identifies the session's model and the context window it is running with
+func ResolveActiveModel(input schema.StatusLineInput, transcriptModel string, contextTokens int, pricing PricingTable) ActiveModel {
+	id := input.Model.ID
+	if id == "" {
+		id = transcriptModel
+	}
+	if id == "" {
+		id = input.Model.DisplayName
+	}
+
+	spec := LookupModelSpec(id)
+	model := ActiveModel{
+		ID:           id,
+		Spec:         spec,
+		Pricing:      pricing.Lookup(id),
+		ContextLimit: spec.ContextWindow,
+	}
+	if isExtendedContext(input, id) || contextTokens > spec.ContextWindow {
+		if spec.ExtendedContextWindow > 0 {
+			model.ContextLimit = spec.ExtendedContextWindow
+		} else {
+			model.ContextLimit = SonnetContextLimit // The model evidently has a larger window than we know of
+		}
+	}
+	return model
+}
+
+// isExtendedContext reports whether the session runs with the 1M context window
+func isExtendedContext(input schema.StatusLineInput, id string) bool {
+	if input.Exceeds200kTokens {
+		return true
+	}
+	if strings.Contains(strings.ToLower(id), "[1m]") {
+		return true
+	}
+	return strings.Contains(strings.ToLower(input.Model.DisplayName), "1m context")
+}
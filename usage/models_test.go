@@ -0,0 +1,94 @@
+package usage
+
+import (
+	"testing"
+
+	"github.com/mrdavidaylward/ccstatus/schema"
+)
+
+// TestLookupModelSpec tests model ID resolution against the registry
+func TestLookupModelSpec(t *testing.T) {
+	tests := []struct {
+		name          string
+		model         string
+		wantMaxOutput int
+		wantExtended  int
+		wantInput     float64
+	}{
+		{name: "dated sonnet", model: "claude-sonnet-4-5-20250929", wantMaxOutput: 64000, wantExtended: SonnetContextLimit, wantInput: 3},
+		{name: "opus 4.1", model: "claude-opus-4-1-20250805", wantMaxOutput: 32000, wantInput: 15},
+		{name: "haiku 3.5", model: "claude-3-5-haiku-20241022", wantMaxOutput: 8192, wantInput: 0.80},
+		{name: "display name", model: "Opus", wantMaxOutput: 64000, wantInput: 5},
+		{name: "versioned display name", model: "Haiku 4.5", wantMaxOutput: 64000, wantInput: 1},
+		{name: "unknown", model: "mystery", wantMaxOutput: MaxOutputTokens, wantExtended: SonnetContextLimit, wantInput: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := LookupModelSpec(tt.model)
+			if got.ContextWindow != StandardContextLimit || got.MaxOutput != tt.wantMaxOutput || got.ExtendedContextWindow != tt.wantExtended {
+				t.Errorf("LookupModelSpec(%q) = %+v", tt.model, got)
+			}
+			if got.Pricing.Input != tt.wantInput || got.Pricing.CacheRead == 0 {
+				t.Errorf("LookupModelSpec(%q).Pricing = %+v, want input %v with cache rates", tt.model, got.Pricing, tt.wantInput)
+			}
+			if pricing := DefaultPricing.Lookup(tt.model); pricing.PricingRates != got.Pricing.PricingRates {
+				t.Errorf("DefaultPricing.Lookup(%q) = %+v, want the registry's %+v", tt.model, pricing, got.Pricing)
+			}
+		})
+	}
+}
+
+// TestResolveActiveModel tests picking the context window in effect
+func TestResolveActiveModel(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         schema.StatusLineInput
+		transcript    string
+		contextTokens int
+		wantID        string
+		wantLimit     int
+	}{
+		{
+			name:      "standard window",
+			input:     schema.StatusLineInput{Model: schema.ModelInfo{ID: "claude-sonnet-4-5-20250929"}},
+			wantID:    "claude-sonnet-4-5-20250929",
+			wantLimit: StandardContextLimit,
+		},
+		{
+			name:      "1m variant",
+			input:     schema.StatusLineInput{Model: schema.ModelInfo{ID: "claude-sonnet-4-5-20250929[1m]"}},
+			wantID:    "claude-sonnet-4-5-20250929[1m]",
+			wantLimit: SonnetContextLimit,
+		},
+		{
+			name:      "exceeds 200k flag",
+			input:     schema.StatusLineInput{Model: schema.ModelInfo{ID: "claude-sonnet-4-20250514"}, Exceeds200kTokens: true},
+			wantID:    "claude-sonnet-4-20250514",
+			wantLimit: SonnetContextLimit,
+		},
+		{
+			name:          "context larger than standard window",
+			input:         schema.StatusLineInput{Model: schema.ModelInfo{ID: "claude-sonnet-4-20250514"}},
+			contextTokens: 250000,
+			wantID:        "claude-sonnet-4-20250514",
+			wantLimit:     SonnetContextLimit,
+		},
+		{
+			name:       "model from transcript",
+			input:      schema.StatusLineInput{Model: schema.ModelInfo{DisplayName: "Opus"}},
+			transcript: "claude-opus-4-1-20250805",
+			wantID:     "claude-opus-4-1-20250805",
+			wantLimit:  StandardContextLimit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ResolveActiveModel(tt.input, tt.transcript, tt.contextTokens, DefaultPricing)
+			if got.ID != tt.wantID || got.ContextLimit != tt.wantLimit {
+				t.Errorf("ResolveActiveModel() = %s/%d, want %s/%d", got.ID, got.ContextLimit, tt.wantID, tt.wantLimit)
+			}
+		})
+	}
+}
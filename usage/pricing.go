@@ -0,0 +1,110 @@
+package usage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/mrdavidaylward/ccstatus/internal/debug"
+)
+
+// LongContextThreshold is the prompt size above which long-context pricing applies
+const LongContextThreshold = 200000
+
+// PricingRates are USD prices per 1M tokens
+type PricingRates struct {
+	Input        float64 `json:"input"`
+	Output       float64 `json:"output"`
+	CacheWrite5m float64 `json:"cache_write_5m"`
+	CacheWrite1h float64 `json:"cache_write_1h"`
+	CacheRead    float64 `json:"cache_read"`
+}
+
+// ModelPricing holds a model's standard rates and optional long-context premium rates
+type ModelPricing struct {
+	PricingRates
+	LongContext *PricingRates `json:"long_context,omitempty"`
+}
+
+// PricingTable maps model IDs (without date suffix) to their pricing
+type PricingTable map[string]ModelPricing
+
+// DefaultPricing is the model registry's pricing, which override files are merged over
+var DefaultPricing = registryPricing()
+
+// registryPricing collects the pricing of every model in the registry
+func registryPricing() PricingTable {
+	table := make(PricingTable, len(modelRegistry))
+	for id, spec := range modelRegistry {
+		table[id] = spec.Pricing
+	}
+	return table
+}
+
+// Lookup finds pricing by ID, falling back to the newest model of its family
+func (t PricingTable) Lookup(model string) ModelPricing {
+	if pricing, ok := lookupModel(t, model); ok {
+		return pricing
+	}
+	return LookupModelSpec(model).Pricing
+}
+
+// Cost prices one usage block, using long-context rates when requested and available
+func (p ModelPricing) Cost(u TokenUsage, longContext bool) float64 {
+	rates := p.PricingRates
+	if longContext && p.LongContext != nil {
+		rates = *p.LongContext
+	}
+
+	cache5m, cache1h := u.CacheCreation.Ephemeral5mInputTokens, u.CacheCreation.Ephemeral1hInputTokens
+	if cache5m == 0 && cache1h == 0 {
+		cache5m = u.CacheCreationInputTokens // Older transcripts don't split by TTL
+	}
+
+	return (float64(u.InputTokens)*rates.Input +
+		float64(u.OutputTokens)*rates.Output +
+		float64(cache5m)*rates.CacheWrite5m +
+		float64(cache1h)*rates.CacheWrite1h +
+		float64(u.CacheReadInputTokens)*rates.CacheRead) / 1000000
+}
+
+// SessionCost prices per-model usage split by context tier
+func (t PricingTable) SessionCost(models map[string]TieredUsage) float64 {
+	var total float64
+	for model, usage := range models {
+		pricing := t.Lookup(model)
+		total += pricing.Cost(usage.Standard, false)
+		total += pricing.Cost(usage.LongContext, true)
+	}
+	return total
+}
+
+// LoadPricingTable merges the override file at path over the built-in table
+func LoadPricingTable(path string) (PricingTable, error) {
+	table := make(PricingTable, len(DefaultPricing))
+	for id, pricing := range DefaultPricing {
+		table[id] = pricing
+	}
+	if path == "" {
+		return table, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return table, nil
+	}
+	if err != nil {
+		return table, err
+	}
+
+	var overrides PricingTable
+	if err := json.Unmarshal(content, &overrides); err != nil {
+		return table, fmt.Errorf("%s: %w", path, err)
+	}
+	for id, pricing := range overrides {
+		table[normalizeModelID(id)] = pricing
+	}
+
+	debug.Log("Loaded %d pricing overrides from %s", len(overrides), path)
+	return table, nil
+}
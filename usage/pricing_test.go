@@ -0,0 +1,113 @@
+package usage
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestPricingLookup tests model ID resolution
+func TestPricingLookup(t *testing.T) {
+	tests := []struct {
+		name      string
+		model     string
+		wantInput float64
+	}{
+		{name: "dated id", model: "claude-sonnet-4-20250514", wantInput: 3},
+		{name: "1m variant", model: "claude-sonnet-4-5-20250929[1m]", wantInput: 3},
+		{name: "opus 4.1", model: "claude-opus-4-1-20250805", wantInput: 15},
+		{name: "opus 4.5", model: "claude-opus-4-5-20251101", wantInput: 5},
+		{name: "haiku 4.5", model: "claude-haiku-4-5-20251001", wantInput: 1},
+		{name: "haiku 3.5", model: "claude-3-5-haiku-20241022", wantInput: 0.80},
+		{name: "display name", model: "Haiku", wantInput: 1},
+		{name: "unknown", model: "mystery", wantInput: 3},
+		// Not the $15 of claude-opus-4, which it shares a prefix with
+		{name: "unlisted opus", model: "claude-opus-4-9-20270101", wantInput: 5},
+		{name: "unlisted haiku", model: "claude-haiku-4-7", wantInput: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DefaultPricing.Lookup(tt.model)
+			if got.Input != tt.wantInput {
+				t.Errorf("Lookup(%q).Input = %v, want %v", tt.model, got.Input, tt.wantInput)
+			}
+		})
+	}
+}
+
+// TestModelPricingCost tests cache and long-context pricing
+func TestModelPricingCost(t *testing.T) {
+	tests := []struct {
+		name        string
+		usage       TokenUsage
+		longContext bool
+		want        float64
+	}{
+		{
+			name:  "cache read and legacy cache write",
+			usage: TokenUsage{InputTokens: 1000, OutputTokens: 1000, CacheCreationInputTokens: 1000, CacheReadInputTokens: 10000},
+			want:  (1000*3 + 1000*15 + 1000*3.75 + 10000*0.30) / 1000000,
+		},
+		{
+			name: "split 5m and 1h cache writes",
+			usage: TokenUsage{CacheCreationInputTokens: 3000, CacheCreation: CacheCreationUsage{
+				Ephemeral5mInputTokens: 1000, Ephemeral1hInputTokens: 2000,
+			}},
+			want: (1000*3.75 + 2000*6.0) / 1000000,
+		},
+		{
+			name:        "long context tier",
+			usage:       TokenUsage{InputTokens: 1000, OutputTokens: 1000},
+			longContext: true,
+			want:        (1000*6 + 1000*22.50) / 1000000,
+		},
+	}
+
+	pricing := DefaultPricing.Lookup("claude-sonnet-4-5")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pricing.Cost(tt.usage, tt.longContext)
+			if math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("Cost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Models without a long-context tier keep their standard rates
+	opus := DefaultPricing.Lookup("claude-opus-4-1")
+	if got := opus.Cost(TokenUsage{InputTokens: 1000000}, true); got != 15 {
+		t.Errorf("opus long-context cost() = %v, want 15", got)
+	}
+}
+
+// TestLoadPricingTable tests merging overrides over the built-in table
+func TestLoadPricingTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pricing.json")
+	content := `{"claude-sonnet-4-20250514": {"input": 2, "output": 10}, "claude-new-model": {"input": 7, "output": 9}}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	table, err := LoadPricingTable(path)
+	if err != nil {
+		t.Fatalf("LoadPricingTable() error = %v", err)
+	}
+	if got := table.Lookup("claude-sonnet-4").Input; got != 2 {
+		t.Errorf("overridden sonnet input = %v, want 2", got)
+	}
+	if got := table.Lookup("claude-new-model-20260101").Output; got != 9 {
+		t.Errorf("new model output = %v, want 9", got)
+	}
+	if got := table.Lookup("claude-opus-4-1").Input; got != 15 {
+		t.Errorf("untouched opus input = %v, want 15", got)
+	}
+	if DefaultPricing.Lookup("claude-sonnet-4").Input != 3 {
+		t.Error("LoadPricingTable() modified the built-in table")
+	}
+
+	if _, err := LoadPricingTable(filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Errorf("LoadPricingTable() missing file error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,255 @@
+package usage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	"github.com/mrdavidaylward/ccstatus/internal/cache"
+	"github.com/mrdavidaylward/ccstatus/internal/debug"
+	"github.com/mrdavidaylward/ccstatus/schema"
+)
+
+// transcriptCacheTTL is long because entries are checked against the file's size and mtime
+const transcriptCacheTTL = time.Hour
+
+// TokenUsage is the usage block Claude Code records on each assistant message
+type TokenUsage struct {
+	InputTokens              int                `json:"input_tokens"`
+	OutputTokens             int                `json:"output_tokens"`
+	CacheCreationInputTokens int                `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int                `json:"cache_read_input_tokens"`
+	CacheCreation            CacheCreationUsage `json:"cache_creation"`
+}
+
+// CacheCreationUsage splits cache writes by TTL, which are priced differently
+type CacheCreationUsage struct {
+	Ephemeral5mInputTokens int `json:"ephemeral_5m_input_tokens"`
+	Ephemeral1hInputTokens int `json:"ephemeral_1h_input_tokens"`
+}
+
+// TieredUsage splits a model's usage by whether requests exceeded LongContextThreshold
+type TieredUsage struct {
+	Standard    TokenUsage
+	LongContext TokenUsage
+}
+
+// TotalTokens returns input, output and cache tokens combined
+func (u TokenUsage) TotalTokens() int {
+	return u.InputTokens + u.OutputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+}
+
+// Add accumulates another usage block into u
+func (u *TokenUsage) Add(other TokenUsage) {
+	u.InputTokens += other.InputTokens
+	u.OutputTokens += other.OutputTokens
+	u.CacheCreationInputTokens += other.CacheCreationInputTokens
+	u.CacheReadInputTokens += other.CacheReadInputTokens
+	u.CacheCreation.Ephemeral5mInputTokens += other.CacheCreation.Ephemeral5mInputTokens
+	u.CacheCreation.Ephemeral1hInputTokens += other.CacheCreation.Ephemeral1hInputTokens
+}
+
+// ContextTokens returns the prompt size the request was sent with
+func (u TokenUsage) ContextTokens() int {
+	return u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+}
+
+// TranscriptUsage summarizes a single session transcript
+type TranscriptUsage struct {
+	Usage         TokenUsage
+	Models        map[string]TieredUsage // Per-model usage for pricing
+	Messages      int                    // User prompts, excluding tool results and meta messages
+	ContextTokens int                    // Context size from the last main-chain assistant message
+	Model         string                 // Model of the last assistant message
+	LastActivity  time.Time
+}
+
+// cachedTranscript is a transcript's usage with the file state it was parsed from
+type cachedTranscript struct {
+	Size    int64
+	ModTime time.Time
+	Usage   TranscriptUsage
+}
+
+// transcriptEntry is one line of a Claude Code JSONL transcript
+type transcriptEntry struct {
+	Type        string             `json:"type"`
+	IsSidechain bool               `json:"isSidechain"`
+	IsMeta      bool               `json:"isMeta"`
+	RequestID   string             `json:"requestId"`
+	Timestamp   time.Time          `json:"timestamp"`
+	Message     *transcriptMessage `json:"message"`
+}
+
+// transcriptMessage is the API message embedded in a transcript entry
+type transcriptMessage struct {
+	ID      string          `json:"id"`
+	Model   string          `json:"model"`
+	Usage   *TokenUsage     `json:"usage"`
+	Content json.RawMessage `json:"content"`
+}
+
+// dedupKey identifies an API response; Claude Code writes one line per content block
+func (e transcriptEntry) dedupKey() string {
+	if e.Message == nil || (e.Message.ID == "" && e.RequestID == "") {
+		return ""
+	}
+	return e.Message.ID + ":" + e.RequestID
+}
+
+// isUserPrompt reports whether the entry is a prompt typed by the user
+func (e transcriptEntry) isUserPrompt() bool {
+	if e.Type != "user" || e.IsMeta || e.Message == nil {
+		return false
+	}
+
+	content := bytes.TrimSpace(e.Message.Content)
+	if len(content) == 0 {
+		return false
+	}
+	if content[0] == '"' {
+		return true
+	}
+
+	// Array content: tool results are sent back as user messages
+	var blocks []struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(content, &blocks); err != nil {
+		return false
+	}
+	for _, block := range blocks {
+		if block.Type == "tool_result" {
+			return false
+		}
+	}
+	return len(blocks) > 0
+}
+
+// parseTranscriptLine decodes a transcript line, skipping blank or malformed lines
+func parseTranscriptLine(line []byte) (transcriptEntry, bool) {
+	var entry transcriptEntry
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return entry, false
+	}
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return entry, false
+	}
+	return entry, true
+}
+
+// readTranscriptLines calls fn for every complete line in r
+func readTranscriptLines(r io.Reader, fn func(line []byte)) error {
+	// bufio.Reader rather than Scanner: tool results can exceed any fixed line limit
+	reader := bufio.NewReaderSize(r, 64*1024)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 && (err == nil || err == io.EOF) {
+			fn(line)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// ParseTranscript reads a session transcript and totals its token usage
+func ParseTranscript(path string) (TranscriptUsage, error) {
+	var usage TranscriptUsage
+
+	file, err := os.Open(path)
+	if err != nil {
+		return usage, err
+	}
+	defer file.Close()
+
+	seen := make(map[string]bool)
+	err = readTranscriptLines(file, func(line []byte) {
+		entry, ok := parseTranscriptLine(line)
+		if !ok {
+			return
+		}
+
+		if entry.isUserPrompt() {
+			usage.Messages++
+		}
+
+		if entry.Type != "assistant" || entry.Message == nil || entry.Message.Usage == nil {
+			return
+		}
+		if key := entry.dedupKey(); key != "" {
+			if seen[key] {
+				return
+			}
+			seen[key] = true
+		}
+
+		u := *entry.Message.Usage
+		usage.Usage.Add(u)
+
+		if usage.Models == nil {
+			usage.Models = make(map[string]TieredUsage)
+		}
+		tiered := usage.Models[entry.Message.Model]
+		if u.ContextTokens() > LongContextThreshold {
+			tiered.LongContext.Add(u)
+		} else {
+			tiered.Standard.Add(u)
+		}
+		usage.Models[entry.Message.Model] = tiered
+
+		if entry.Timestamp.After(usage.LastActivity) {
+			usage.LastActivity = entry.Timestamp
+		}
+
+		// Subagent requests have their own context; only the main chain reflects ours
+		if !entry.IsSidechain {
+			usage.ContextTokens = u.ContextTokens()
+			usage.Model = entry.Message.Model
+		}
+	})
+
+	debug.Log("Parsed transcript %s: %d tokens, %d messages, context %d",
+		path, usage.Usage.TotalTokens(), usage.Messages, usage.ContextTokens)
+	return usage, err
+}
+
+// LoadTranscriptUsage parses the transcript referenced by the status line input, if any.
+// Results are cached in env's cache per session and transcript; a change in the file's size or mtime
+// makes the entry stale, so an unchanged transcript is never re-read.
+func LoadTranscriptUsage(input schema.StatusLineInput, env schema.Env) TranscriptUsage {
+	if input.TranscriptPath == "" {
+		return TranscriptUsage{}
+	}
+
+	info, err := os.Stat(input.TranscriptPath)
+	if err != nil {
+		debug.Log("Failed to stat transcript %s: %v", input.TranscriptPath, err)
+		return TranscriptUsage{}
+	}
+
+	var cached cachedTranscript
+	key := cache.Key(input.SessionID, input.TranscriptPath)
+	if cache.Get(env, "transcript", key, transcriptCacheTTL, &cached) &&
+		cached.Size == info.Size() && cached.ModTime.Equal(info.ModTime()) {
+		return cached.Usage
+	}
+
+	usage, err := ParseTranscript(input.TranscriptPath)
+	if err != nil {
+		debug.Log("Failed to parse transcript %s: %v", input.TranscriptPath, err)
+		return TranscriptUsage{}
+	}
+	cached = cachedTranscript{Size: info.Size(), ModTime: info.ModTime(), Usage: usage}
+	if err := cache.Put(env, "transcript", key, cached); err != nil {
+		debug.Log("Failed to cache transcript usage: %v", err)
+	}
+	return usage
+}
@@ -0,0 +1,116 @@
+package usage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mrdavidaylward/ccstatus/schema"
+)
+
+const sampleTranscript = `{"type":"summary","summary":"Refactor widgets"}
+{"type":"user","message":{"role":"user","content":"fix the tests"},"timestamp":"2025-09-02T09:00:00.000Z"}
+{"type":"assistant","requestId":"req_1","timestamp":"2025-09-02T09:00:05.000Z","message":{"id":"msg_1","model":"claude-sonnet-4-20250514","usage":{"input_tokens":10,"output_tokens":100,"cache_creation_input_tokens":2000,"cache_read_input_tokens":5000}}}
+{"type":"assistant","requestId":"req_1","timestamp":"2025-09-02T09:00:06.000Z","message":{"id":"msg_1","model":"claude-sonnet-4-20250514","usage":{"input_tokens":10,"output_tokens":100,"cache_creation_input_tokens":2000,"cache_read_input_tokens":5000}}}
+{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"ok"}]},"timestamp":"2025-09-02T09:00:07.000Z"}
+{"type":"assistant","isSidechain":true,"requestId":"req_2","timestamp":"2025-09-02T09:00:08.000Z","message":{"id":"msg_2","model":"claude-haiku","usage":{"input_tokens":50,"output_tokens":20,"cache_creation_input_tokens":0,"cache_read_input_tokens":90000}}}
+not json at all
+{"type":"user","isMeta":true,"message":{"role":"user","content":"<local-command-stdout></local-command-stdout>"}}
+{"type":"user","message":{"role":"user","content":[{"type":"text","text":"now run them"}]},"timestamp":"2025-09-02T09:01:00.000Z"}
+{"type":"assistant","requestId":"req_3","timestamp":"2025-09-02T09:01:05.000Z","message":{"id":"msg_3","model":"claude-sonnet-4-20250514","usage":{"input_tokens":5,"output_tokens":40,"cache_creation_input_tokens":300,"cache_read_input_tokens":7000}}}`
+
+// TestParseTranscript tests token totals, deduplication and context size
+func TestParseTranscript(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(path, []byte(sampleTranscript), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ParseTranscript(path)
+	if err != nil {
+		t.Fatalf("ParseTranscript() error = %v", err)
+	}
+
+	want := TokenUsage{
+		InputTokens:              65,
+		OutputTokens:             160,
+		CacheCreationInputTokens: 2300,
+		CacheReadInputTokens:     102000,
+	}
+	if got.Usage != want {
+		t.Errorf("ParseTranscript() usage = %+v, want %+v", got.Usage, want)
+	}
+	if got.Messages != 2 {
+		t.Errorf("ParseTranscript() messages = %d, want 2", got.Messages)
+	}
+	if got.ContextTokens != 7305 {
+		t.Errorf("ParseTranscript() context = %d, want 7305 (sidechain excluded)", got.ContextTokens)
+	}
+	if len(got.Models) != 2 || got.Models["claude-sonnet-4-20250514"].Standard.OutputTokens != 140 {
+		t.Errorf("ParseTranscript() models = %+v", got.Models)
+	}
+	if got.Model != "claude-sonnet-4-20250514" {
+		t.Errorf("ParseTranscript() model = %q", got.Model)
+	}
+	if got.LastActivity.Format("15:04:05") != "09:01:05" {
+		t.Errorf("ParseTranscript() last activity = %v", got.LastActivity)
+	}
+}
+
+// TestParseTranscriptMissing tests that a missing transcript is an error
+func TestParseTranscriptMissing(t *testing.T) {
+	if _, err := ParseTranscript(filepath.Join(t.TempDir(), "missing.jsonl")); err == nil {
+		t.Error("ParseTranscript() expected error for missing file")
+	}
+	if got := LoadTranscriptUsage(schema.StatusLineInput{}, nil); got.Usage.TotalTokens() != 0 {
+		t.Errorf("LoadTranscriptUsage() without path = %+v, want empty", got)
+	}
+}
+
+// TestLoadTranscriptUsageCache tests that a session keeps one cache entry, refreshed when
+// the transcript grows
+func TestLoadTranscriptUsageCache(t *testing.T) {
+	cacheHome := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheHome)
+
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	lines := strings.Split(sampleTranscript, "\n")
+	if err := os.WriteFile(path, []byte(strings.Join(lines[:3], "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	input := schema.StatusLineInput{SessionID: "abc", TranscriptPath: path}
+
+	if got := LoadTranscriptUsage(input, nil); got.Messages != 1 {
+		t.Errorf("LoadTranscriptUsage() messages = %d, want 1", got.Messages)
+	}
+	if err := os.WriteFile(path, []byte(sampleTranscript), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := LoadTranscriptUsage(input, nil); got.Messages != 2 {
+		t.Errorf("LoadTranscriptUsage() after append messages = %d, want 2", got.Messages)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(cacheHome, "ccstatus", "transcript"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("transcript cache has %d entries, want 1", len(entries))
+	}
+}
+
+// TestReadTranscriptLinesLong tests lines longer than the reader buffer
+func TestReadTranscriptLinesLong(t *testing.T) {
+	long := strings.Repeat("x", 200*1024)
+	var lines int
+	err := readTranscriptLines(strings.NewReader(long+"\nshort"), func(line []byte) {
+		lines++
+	})
+	if err != nil {
+		t.Fatalf("readTranscriptLines() error = %v", err)
+	}
+	if lines != 2 {
+		t.Errorf("readTranscriptLines() lines = %d, want 2", lines)
+	}
+}
@@ -0,0 +1,222 @@
+// Package usage turns token counts into the metrics the status line shows:
+// plan limits and percentages, transcript and cross-project usage, model specs and pricing.
+package usage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mrdavidaylward/ccstatus/schema"
+)
+
+// CCUsageData represents parsed ccusage output
+type CCUsageData struct {
+	SessionTokens int
+	DailyTokens   int
+	WeeklyTokens  int
+	Messages      int
+	InputTokens   int
+	OutputTokens  int
+	SessionID     string
+}
+
+// CalculatedUsage represents calculated usage from conversation history
+type CalculatedUsage struct {
+	SessionTokens int
+	DailyTokens   int
+	WeeklyTokens  int
+	Messages      int
+	InputTokens   int
+	OutputTokens  int
+}
+
+// UsagePercentage calculates usage percentage from various sources
+func UsagePercentage(dailyTokens, contextTokens, contextChars, contextLimit int) int {
+	contextPercentage := 0
+	if contextTokens > 0 {
+		contextPercentage = int((contextTokens * 100) / contextLimit)
+	} else if contextChars > 0 {
+		estimatedTokens := contextChars / 4
+		contextPercentage = int((estimatedTokens * 100) / contextLimit)
+	}
+
+	// Cap context percentage at 100%
+	if contextPercentage > 100 {
+		contextPercentage = 100
+	}
+
+	// Weekly usage percentage (more restrictive than daily in 2025)
+	weeklyPercentage := int((dailyTokens * 100) / WeeklyTokenEstimate)
+
+	// Cap weekly percentage at 100%
+	if weeklyPercentage > 100 {
+		weeklyPercentage = 100
+	}
+
+	// Return the most restrictive limit
+	if contextPercentage > weeklyPercentage {
+		return contextPercentage
+	}
+	return weeklyPercentage
+}
+
+// MessageCount gets the current message count in rate limit window. The transcript's
+// prompt count spans the whole session, so it is not used here.
+func MessageCount(ccusageData CCUsageData, calculatedUsage CalculatedUsage) int {
+	if calculatedUsage.Messages > 0 {
+		return calculatedUsage.Messages
+	}
+	if ccusageData.Messages > 0 {
+		return ccusageData.Messages
+	}
+	return 0
+}
+
+// ContextEfficiency calculates context window utilization percentage
+func ContextEfficiency(contextTokens, contextLimit int) float64 {
+	if contextTokens == 0 {
+		return 0.0
+	}
+	efficiency := (float64(contextTokens) / float64(contextLimit)) * 100
+	if efficiency > 100.0 {
+		efficiency = 100.0 // Cap at 100%
+	}
+	return efficiency
+}
+
+// WeeklyUsagePercentage calculates percentage of weekly limit used
+func WeeklyUsagePercentage(weeklyTokens int) int {
+	if weeklyTokens == 0 {
+		return 0
+	}
+	percentage := int((float64(weeklyTokens) / float64(WeeklyTokenEstimate)) * 100)
+	if percentage > 100 {
+		percentage = 100 // Cap at 100%
+	}
+	return percentage
+}
+
+// DailyUsagePercentage calculates percentage of daily usage
+func DailyUsagePercentage(dailyTokens int) int {
+	if dailyTokens == 0 {
+		return 0
+	}
+	// Estimate daily portion of weekly limit (1/7 of weekly)
+	dailyEstimate := WeeklyTokenEstimate / 7
+	percentage := int((float64(dailyTokens) / float64(dailyEstimate)) * 100)
+	if percentage > 100 {
+		percentage = 100 // Cap at 100%
+	}
+	return percentage
+}
+
+// WeeklyTokensUsed gets weekly token usage with fallback logic
+func WeeklyTokensUsed(ccusageData CCUsageData, calculatedUsage CalculatedUsage) int {
+	if calculatedUsage.WeeklyTokens > 0 {
+		return calculatedUsage.WeeklyTokens
+	}
+	if ccusageData.WeeklyTokens > 0 {
+		return ccusageData.WeeklyTokens
+	}
+	// Fallback: estimate from daily (assume 7-day average)
+	if ccusageData.DailyTokens > 0 {
+		return ccusageData.DailyTokens * 7 / 7 // This would need better logic
+	}
+	return 0
+}
+
+// TimeToWeeklyReset calculates time until weekly limit resets
+func TimeToWeeklyReset() (string, string) {
+	now := time.Now().In(time.UTC)
+	duration := WeeklyResetTime(now).Sub(now)
+
+	days := int(duration.Hours() / 24)
+	hours := int(duration.Hours()) % 24
+	minutes := int(duration.Minutes()) % 60
+
+	var timeStr string
+	if days > 0 {
+		timeStr = fmt.Sprintf("%dd %dh", days, hours)
+	} else if hours > 0 {
+		timeStr = fmt.Sprintf("%dh %dm", hours, minutes)
+	} else {
+		timeStr = fmt.Sprintf("%dm", minutes)
+	}
+
+	return timeStr, "weekly"
+}
+
+// WeeklyResetTime returns the next weekly reset (Mondays at 00:00 UTC) after now
+func WeeklyResetTime(now time.Time) time.Time {
+	now = now.In(time.UTC)
+
+	// Calculate days until next Monday
+	// Sunday = 0, Monday = 1, ..., Saturday = 6
+	daysUntilMonday := (8 - int(now.Weekday())) % 7
+	if daysUntilMonday == 0 {
+		daysUntilMonday = 7 // If today is Monday, next reset is next Monday
+	}
+
+	nextMonday := now.AddDate(0, 0, daysUntilMonday)
+	return time.Date(nextMonday.Year(), nextMonday.Month(), nextMonday.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// CompactionPercentage calculates how close we are to hitting compaction
+func CompactionPercentage(contextTokens, contextLimit int) int {
+	if contextTokens == 0 {
+		return 0
+	}
+
+	// Most models start compaction around 90% of context limit
+	compactionThreshold := int(float64(contextLimit) * 0.9) // 180K tokens for 200K limit
+
+	if contextTokens >= compactionThreshold {
+		// We're in the danger zone - show percentage from threshold to limit
+		remaining := contextLimit - contextTokens
+		dangerZone := contextLimit - compactionThreshold
+		percentage := int((float64(dangerZone-remaining) / float64(dangerZone)) * 100)
+		if percentage > 100 {
+			return 100
+		}
+		return percentage
+	}
+
+	// Show percentage to threshold
+	return int((float64(contextTokens) / float64(compactionThreshold)) * 100)
+}
+
+// EstimateCost calculates session and daily costs based on model and token usage
+func EstimateCost(modelName string, inputTokens, outputTokens int) (sessionCost, dailyCost float64) {
+	pricing := DefaultPricing.Lookup(modelName)
+
+	sessionCost = (float64(inputTokens)*pricing.Input + float64(outputTokens)*pricing.Output) / 1000000
+	dailyCost = sessionCost
+
+	return sessionCost, dailyCost
+}
+
+// BlockTimerDisplay returns the block timer display
+func BlockTimerDisplay(env schema.Env) string {
+	now := time.Now()
+
+	// Use the active block from the native usage aggregator when available
+	blockStartTime := time.Time{}
+	if summary, ok := CachedSummary(env); ok {
+		blockStartTime = summary.Block.Start
+	}
+	if blockStartTime.IsZero() {
+		// Simulate block start (5-hour windows from midnight)
+		hour := now.Hour()
+		blockStart := (hour / 5) * 5
+		blockStartTime = time.Date(now.Year(), now.Month(), now.Day(), blockStart, 0, 0, 0, now.Location())
+	}
+
+	elapsed := now.Sub(blockStartTime)
+	hours := int(elapsed.Hours())
+	minutes := int(elapsed.Minutes()) % 60
+
+	if hours == 0 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %dm", hours, minutes)
+}